@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	distributiontypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	minttypes "github.com/cosmos/cosmos-sdk/x/mint/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"google.golang.org/grpc"
+)
+
+// StakingAPRSource calculates the nominal staking APR before validators commission.
+// Chains that replaced the mint module with their own one can have their own source.
+type StakingAPRSource interface {
+	GetStakingAPR(grpcConn *grpc.ClientConn) (float64, error)
+}
+
+// MintStakingAPRSource takes the annual provisions from the mint module and splits them
+// between the bonded tokens. Proposer rewards are not subtracted, as they are distributed
+// proportionally to voting power on average, same as the rest of the rewards.
+type MintStakingAPRSource struct{}
+
+func (s MintStakingAPRSource) GetStakingAPR(grpcConn *grpc.ClientConn) (float64, error) {
+	mintClient := minttypes.NewQueryClient(grpcConn)
+	provisionsResponse, err := mintClient.AnnualProvisions(
+		context.Background(),
+		&minttypes.QueryAnnualProvisionsRequest{},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	stakingClient := stakingtypes.NewQueryClient(grpcConn)
+	poolResponse, err := stakingClient.Pool(
+		context.Background(),
+		&stakingtypes.QueryPoolRequest{},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	distributionClient := distributiontypes.NewQueryClient(grpcConn)
+	paramsResponse, err := distributionClient.Params(
+		context.Background(),
+		&distributiontypes.QueryParamsRequest{},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
+	annualProvisions, err := strconv.ParseFloat(provisionsResponse.AnnualProvisions.String(), 64)
+	if err != nil {
+		return 0, err
+	}
+
+	bondedTokens, err := strconv.ParseFloat(poolResponse.Pool.BondedTokens.String(), 64)
+	if err != nil {
+		return 0, err
+	}
+
+	communityTax, err := strconv.ParseFloat(paramsResponse.Params.CommunityTax.String(), 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if bondedTokens == 0 {
+		return 0, fmt.Errorf("no bonded tokens")
+	}
+
+	return annualProvisions * (1 - communityTax) / bondedTokens, nil
+}
+
+// StaticStakingAPRSource always returns the APR provided with --staking-apr.
+type StaticStakingAPRSource struct {
+	APR float64
+}
+
+func (s StaticStakingAPRSource) GetStakingAPR(grpcConn *grpc.ClientConn) (float64, error) {
+	return s.APR, nil
+}
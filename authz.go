@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	querytypes "github.com/cosmos/cosmos-sdk/types/query"
+	gogotypes "github.com/gogo/protobuf/types"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+)
+
+// see cosmos/authz/v1beta1/query.proto and authz.proto
+
+type authzGranterGrantsRequest struct {
+	Granter    string                  `protobuf:"bytes,1,opt,name=granter,proto3"`
+	Pagination *querytypes.PageRequest `protobuf:"bytes,2,opt,name=pagination,proto3"`
+}
+
+func (m *authzGranterGrantsRequest) Reset()         { *m = authzGranterGrantsRequest{} }
+func (m *authzGranterGrantsRequest) String() string { return proto.CompactTextString(m) }
+func (*authzGranterGrantsRequest) ProtoMessage()    {}
+
+type authzGranteeGrantsRequest struct {
+	Grantee    string                  `protobuf:"bytes,1,opt,name=grantee,proto3"`
+	Pagination *querytypes.PageRequest `protobuf:"bytes,2,opt,name=pagination,proto3"`
+}
+
+func (m *authzGranteeGrantsRequest) Reset()         { *m = authzGranteeGrantsRequest{} }
+func (m *authzGranteeGrantsRequest) String() string { return proto.CompactTextString(m) }
+func (*authzGranteeGrantsRequest) ProtoMessage()    {}
+
+// both GranterGrants and GranteeGrants return the same response
+type authzGrantsResponse struct {
+	Grants     []*authzGrantAuthorization `protobuf:"bytes,1,rep,name=grants,proto3"`
+	Pagination *querytypes.PageResponse   `protobuf:"bytes,2,opt,name=pagination,proto3"`
+}
+
+func (m *authzGrantsResponse) Reset()         { *m = authzGrantsResponse{} }
+func (m *authzGrantsResponse) String() string { return proto.CompactTextString(m) }
+func (*authzGrantsResponse) ProtoMessage()    {}
+
+type authzGrantAuthorization struct {
+	Granter       string               `protobuf:"bytes,1,opt,name=granter,proto3"`
+	Grantee       string               `protobuf:"bytes,2,opt,name=grantee,proto3"`
+	Authorization *codectypes.Any      `protobuf:"bytes,3,opt,name=authorization,proto3"`
+	Expiration    *gogotypes.Timestamp `protobuf:"bytes,4,opt,name=expiration,proto3"`
+}
+
+func (m *authzGrantAuthorization) Reset()         { *m = authzGrantAuthorization{} }
+func (m *authzGrantAuthorization) String() string { return proto.CompactTextString(m) }
+func (*authzGrantAuthorization) ProtoMessage()    {}
+
+type authzGenericAuthorization struct {
+	Msg string `protobuf:"bytes,1,opt,name=msg,proto3"`
+}
+
+func (m *authzGenericAuthorization) Reset()         { *m = authzGenericAuthorization{} }
+func (m *authzGenericAuthorization) String() string { return proto.CompactTextString(m) }
+func (*authzGenericAuthorization) ProtoMessage()    {}
+
+type authzSendAuthorization struct {
+	SpendLimit []*protoCoin `protobuf:"bytes,1,rep,name=spend_limit,proto3"`
+}
+
+func (m *authzSendAuthorization) Reset()         { *m = authzSendAuthorization{} }
+func (m *authzSendAuthorization) String() string { return proto.CompactTextString(m) }
+func (*authzSendAuthorization) ProtoMessage()    {}
+
+// validators allow and deny lists are omitted
+type authzStakeAuthorization struct {
+	MaxTokens         *protoCoin `protobuf:"bytes,1,opt,name=max_tokens,proto3"`
+	AuthorizationType int32      `protobuf:"varint,4,opt,name=authorization_type,proto3"`
+}
+
+func (m *authzStakeAuthorization) Reset()         { *m = authzStakeAuthorization{} }
+func (m *authzStakeAuthorization) String() string { return proto.CompactTextString(m) }
+func (*authzStakeAuthorization) ProtoMessage()    {}
+
+const (
+	authzGenericAuthorizationType = "/cosmos.authz.v1beta1.GenericAuthorization"
+	authzSendAuthorizationType    = "/cosmos.bank.v1beta1.SendAuthorization"
+	authzStakeAuthorizationType   = "/cosmos.staking.v1beta1.StakeAuthorization"
+)
+
+// StakeAuthorization doesn't have the message type, it's defined by the authorization type enum
+var authzStakeAuthorizationMsgTypes = map[int32]string{
+	1: "/cosmos.staking.v1beta1.MsgDelegate",
+	2: "/cosmos.staking.v1beta1.MsgUndelegate",
+	3: "/cosmos.staking.v1beta1.MsgBeginRedelegate",
+}
+
+func AuthzHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.ClientConn) {
+	requestStart := time.Now()
+
+	sublogger := log.With().
+		Str("request-id", uuid.New().String()).
+		Logger()
+
+	address := r.URL.Query().Get("address")
+	myAddress, err := sdk.AccAddressFromBech32(address)
+	if err != nil {
+		sublogger.Error().
+			Str("address", address).
+			Err(err).
+			Msg("Could not get address")
+		return
+	}
+
+	authzGrantGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_authz_grant",
+			Help:        "Authz grant of the Cosmos-based blockchain wallet, always 1",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"granter", "grantee", "msg_type_url", "authorization_type"},
+	)
+
+	authzGrantSpendLimitGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_authz_grant_spend_limit",
+			Help:        "Spend limit of the authz grant of the Cosmos-based blockchain wallet",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"granter", "grantee", "msg_type_url", "denom"},
+	)
+
+	authzGrantExpirationGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_authz_grant_expiration",
+			Help:        "Expiration time of the authz grant of the Cosmos-based blockchain wallet, in unix seconds",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"granter", "grantee", "msg_type_url"},
+	)
+
+	authzGrantExpirationRemainingGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_authz_grant_expiration_remaining",
+			Help:        "Seconds left until the authz grant of the Cosmos-based blockchain wallet expires, negative if it has expired",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"granter", "grantee", "msg_type_url"},
+	)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(authzGrantGauge)
+	registry.MustRegister(authzGrantSpendLimitGauge)
+	registry.MustRegister(authzGrantExpirationGauge)
+	registry.MustRegister(authzGrantExpirationRemainingGauge)
+
+	var mutex sync.Mutex
+	var grants []*authzGrantAuthorization
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sublogger.Debug().
+			Str("address", address).
+			Msg("Started querying granter grants")
+		queryStart := time.Now()
+
+		var pageGrants []*authzGrantAuthorization
+		var nextKey []byte
+
+		for {
+			response := &authzGrantsResponse{}
+			err := grpcConn.Invoke(
+				context.Background(),
+				"/cosmos.authz.v1beta1.Query/GranterGrants",
+				&authzGranterGrantsRequest{
+					Granter: myAddress.String(),
+					Pagination: &querytypes.PageRequest{
+						Key:   nextKey,
+						Limit: Limit,
+					},
+				},
+				response,
+			)
+			if err != nil {
+				sublogger.Error().
+					Str("address", address).
+					Err(err).
+					Msg("Could not get granter grants")
+				return
+			}
+
+			pageGrants = append(pageGrants, response.Grants...)
+
+			if response.Pagination == nil || len(response.Pagination.NextKey) == 0 {
+				break
+			}
+
+			nextKey = response.Pagination.NextKey
+		}
+
+		sublogger.Debug().
+			Str("address", address).
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying granter grants")
+
+		mutex.Lock()
+		grants = append(grants, pageGrants...)
+		mutex.Unlock()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sublogger.Debug().
+			Str("address", address).
+			Msg("Started querying grantee grants")
+		queryStart := time.Now()
+
+		var pageGrants []*authzGrantAuthorization
+		var nextKey []byte
+
+		for {
+			response := &authzGrantsResponse{}
+			err := grpcConn.Invoke(
+				context.Background(),
+				"/cosmos.authz.v1beta1.Query/GranteeGrants",
+				&authzGranteeGrantsRequest{
+					Grantee: myAddress.String(),
+					Pagination: &querytypes.PageRequest{
+						Key:   nextKey,
+						Limit: Limit,
+					},
+				},
+				response,
+			)
+			if err != nil {
+				sublogger.Error().
+					Str("address", address).
+					Err(err).
+					Msg("Could not get grantee grants")
+				return
+			}
+
+			pageGrants = append(pageGrants, response.Grants...)
+
+			if response.Pagination == nil || len(response.Pagination.NextKey) == 0 {
+				break
+			}
+
+			nextKey = response.Pagination.NextKey
+		}
+
+		sublogger.Debug().
+			Str("address", address).
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying grantee grants")
+
+		mutex.Lock()
+		grants = append(grants, pageGrants...)
+		mutex.Unlock()
+	}()
+
+	wg.Wait()
+
+	for _, grant := range grants {
+		if grant.Authorization == nil {
+			continue
+		}
+
+		var msgTypeURL string
+		var spendLimit []*protoCoin
+
+		switch grant.Authorization.TypeUrl {
+		case authzGenericAuthorizationType:
+			authorization := &authzGenericAuthorization{}
+			if err := proto.Unmarshal(grant.Authorization.Value, authorization); err != nil {
+				sublogger.Error().
+					Str("address", address).
+					Err(err).
+					Msg("Could not parse generic authorization")
+				continue
+			}
+
+			msgTypeURL = authorization.Msg
+		case authzSendAuthorizationType:
+			authorization := &authzSendAuthorization{}
+			if err := proto.Unmarshal(grant.Authorization.Value, authorization); err != nil {
+				sublogger.Error().
+					Str("address", address).
+					Err(err).
+					Msg("Could not parse send authorization")
+				continue
+			}
+
+			msgTypeURL = "/cosmos.bank.v1beta1.MsgSend"
+			spendLimit = authorization.SpendLimit
+		case authzStakeAuthorizationType:
+			authorization := &authzStakeAuthorization{}
+			if err := proto.Unmarshal(grant.Authorization.Value, authorization); err != nil {
+				sublogger.Error().
+					Str("address", address).
+					Err(err).
+					Msg("Could not parse stake authorization")
+				continue
+			}
+
+			msgTypeURL = authzStakeAuthorizationMsgTypes[authorization.AuthorizationType]
+			if authorization.MaxTokens != nil {
+				spendLimit = []*protoCoin{authorization.MaxTokens}
+			}
+		default:
+			sublogger.Debug().
+				Str("address", address).
+				Str("type", grant.Authorization.TypeUrl).
+				Msg("Unsupported authorization type, not exporting its details")
+		}
+
+		authzGrantGauge.With(prometheus.Labels{
+			"granter":            grant.Granter,
+			"grantee":            grant.Grantee,
+			"msg_type_url":       msgTypeURL,
+			"authorization_type": grant.Authorization.TypeUrl,
+		}).Set(1)
+
+		for _, coin := range spendLimit {
+			if value, err := strconv.ParseFloat(coin.Amount, 64); err != nil {
+				sublogger.Error().
+					Str("address", address).
+					Err(err).
+					Msg("Could not parse spend limit")
+			} else {
+				denom, value := getCoinValue(coin.Denom, value)
+				authzGrantSpendLimitGauge.With(prometheus.Labels{
+					"granter":      grant.Granter,
+					"grantee":      grant.Grantee,
+					"msg_type_url": msgTypeURL,
+					"denom":        denom,
+				}).Set(value)
+			}
+		}
+
+		// grants without expiration never expire
+		if grant.Expiration == nil {
+			continue
+		}
+
+		expiration, err := gogotypes.TimestampFromProto(grant.Expiration)
+		if err != nil {
+			sublogger.Error().
+				Str("address", address).
+				Err(err).
+				Msg("Could not parse grant expiration")
+			continue
+		}
+
+		authzGrantExpirationGauge.With(prometheus.Labels{
+			"granter":      grant.Granter,
+			"grantee":      grant.Grantee,
+			"msg_type_url": msgTypeURL,
+		}).Set(float64(expiration.Unix()))
+
+		authzGrantExpirationRemainingGauge.With(prometheus.Labels{
+			"granter":      grant.Granter,
+			"grantee":      grant.Grantee,
+			"msg_type_url": msgTypeURL,
+		}).Set(time.Until(expiration).Seconds())
+	}
+
+	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	h.ServeHTTP(w, r)
+	sublogger.Info().
+		Str("method", "GET").
+		Str("endpoint", "/metrics/authz?address="+address).
+		Float64("request-time", time.Since(requestStart).Seconds()).
+		Msg("Request processed")
+}
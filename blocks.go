@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	tmrpc "github.com/tendermint/tendermint/rpc/client/http"
+	"google.golang.org/grpc"
+)
+
+// getAverageBlockTime compares the latest block with the one BlockTimeWindow blocks
+// before it, as there's no way to get the block time from the chain itself.
+func getAverageBlockTime(grpcConn *grpc.ClientConn) (time.Duration, error) {
+	serviceClient := tmservice.NewServiceClient(grpcConn)
+	latestBlock, err := serviceClient.GetLatestBlock(
+		context.Background(),
+		&tmservice.GetLatestBlockRequest{},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	latestHeight := latestBlock.Block.Header.Height
+	olderHeight := latestHeight - int64(BlockTimeWindow)
+	if olderHeight < 1 {
+		olderHeight = 1
+	}
+
+	if olderHeight >= latestHeight {
+		return 0, fmt.Errorf("not enough blocks to calculate block time, latest height is %d", latestHeight)
+	}
+
+	olderBlock, err := serviceClient.GetBlockByHeight(
+		context.Background(),
+		&tmservice.GetBlockByHeightRequest{Height: olderHeight},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	elapsed := latestBlock.Block.Header.Time.Sub(olderBlock.Block.Header.Time)
+	return elapsed / time.Duration(latestHeight-olderHeight), nil
+}
+
+func BlocksHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.ClientConn, tendermintClient *tmrpc.HTTP) {
+	requestStart := time.Now()
+
+	sublogger := log.With().
+		Str("request-id", uuid.New().String()).
+		Logger()
+
+	blockHeightGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_block_height",
+			Help:        "Height of the latest block",
+			ConstLabels: ConstLabels,
+		},
+	)
+
+	blockTimeGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_block_time",
+			Help:        "Time of the latest block, in unix seconds",
+			ConstLabels: ConstLabels,
+		},
+	)
+
+	blockSinceLastGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_block_seconds_since_last",
+			Help:        "Seconds passed since the latest block time, grows if the chain is halted or the node is stuck",
+			ConstLabels: ConstLabels,
+		},
+	)
+
+	blockAverageTimeGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_block_average_time_seconds",
+			Help:        "Average block time over the last --block-time-window blocks, in seconds",
+			ConstLabels: ConstLabels,
+		},
+	)
+
+	blockTxsGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_block_txs",
+			Help:        "Amount of transactions in the latest block",
+			ConstLabels: ConstLabels,
+		},
+	)
+
+	blockSizeGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_block_size",
+			Help:        "Size of the latest block, in bytes",
+			ConstLabels: ConstLabels,
+		},
+	)
+
+	blockGasUsedGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_block_gas_used",
+			Help:        "Gas used by the transactions in the latest block",
+			ConstLabels: ConstLabels,
+		},
+	)
+
+	blockGasWantedGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_block_gas_wanted",
+			Help:        "Gas wanted by the transactions in the latest block",
+			ConstLabels: ConstLabels,
+		},
+	)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(blockHeightGauge)
+	registry.MustRegister(blockTimeGauge)
+	registry.MustRegister(blockSinceLastGauge)
+	registry.MustRegister(blockAverageTimeGauge)
+	registry.MustRegister(blockTxsGauge)
+	registry.MustRegister(blockSizeGauge)
+	registry.MustRegister(blockGasUsedGauge)
+	registry.MustRegister(blockGasWantedGauge)
+
+	sublogger.Debug().Msg("Started querying node status")
+	queryStart := time.Now()
+
+	status, err := tendermintClient.Status(context.Background())
+	if err != nil {
+		// the other queries depend on the latest height, so serving an empty response
+		sublogger.Error().Err(err).Msg("Could not get node status")
+		h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+		h.ServeHTTP(w, r)
+		return
+	}
+
+	sublogger.Debug().
+		Float64("request-time", time.Since(queryStart).Seconds()).
+		Msg("Finished querying node status")
+
+	latestHeight := status.SyncInfo.LatestBlockHeight
+	latestTime := status.SyncInfo.LatestBlockTime
+
+	blockHeightGauge.Set(float64(latestHeight))
+	blockTimeGauge.Set(float64(latestTime.Unix()))
+	blockSinceLastGauge.Set(time.Since(latestTime).Seconds())
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sublogger.Debug().Msg("Started querying latest block")
+		queryStart := time.Now()
+
+		block, err := tendermintClient.Block(context.Background(), &latestHeight)
+		if err != nil {
+			sublogger.Error().Err(err).Msg("Could not get latest block")
+			return
+		}
+
+		sublogger.Debug().
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying latest block")
+
+		blockTxsGauge.Set(float64(len(block.Block.Data.Txs)))
+		blockSizeGauge.Set(float64(block.Block.Size()))
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sublogger.Debug().Msg("Started querying latest block results")
+		queryStart := time.Now()
+
+		blockResults, err := tendermintClient.BlockResults(context.Background(), &latestHeight)
+		if err != nil {
+			sublogger.Error().Err(err).Msg("Could not get latest block results")
+			return
+		}
+
+		sublogger.Debug().
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying latest block results")
+
+		var gasUsed, gasWanted int64
+		for _, txResult := range blockResults.TxsResults {
+			gasUsed += txResult.GasUsed
+			gasWanted += txResult.GasWanted
+		}
+
+		blockGasUsedGauge.Set(float64(gasUsed))
+		blockGasWantedGauge.Set(float64(gasWanted))
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sublogger.Debug().Msg("Started querying average block time")
+		queryStart := time.Now()
+
+		blockTime, err := getAverageBlockTime(grpcConn)
+		if err != nil {
+			sublogger.Error().
+				Err(err).
+				Msg("Could not get average block time")
+			return
+		}
+
+		sublogger.Debug().
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying average block time")
+
+		blockAverageTimeGauge.Set(blockTime.Seconds())
+	}()
+
+	wg.Wait()
+
+	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	h.ServeHTTP(w, r)
+	sublogger.Info().
+		Str("method", "GET").
+		Str("endpoint", "/metrics/blocks").
+		Float64("request-time", time.Since(requestStart).Seconds()).
+		Msg("Request processed")
+}
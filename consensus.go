@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	tmrpc "github.com/tendermint/tendermint/rpc/client/http"
+	"google.golang.org/grpc"
+)
+
+// the Tendermint round state is returned as raw JSON and its types
+// cannot be unmarshalled back, so only taking the fields we need
+type consensusRoundState struct {
+	Height     string `json:"height"`
+	Round      int32  `json:"round"`
+	Step       uint8  `json:"step"`
+	Validators struct {
+		Validators []struct {
+			Address     string `json:"address"`
+			VotingPower string `json:"voting_power"`
+		} `json:"validators"`
+	} `json:"validators"`
+	Votes []consensusRoundVotes `json:"votes"`
+}
+
+type consensusRoundVotes struct {
+	Round              int32    `json:"round"`
+	Prevotes           []string `json:"prevotes"`
+	PrevotesBitArray   string   `json:"prevotes_bit_array"`
+	Precommits         []string `json:"precommits"`
+	PrecommitsBitArray string   `json:"precommits_bit_array"`
+}
+
+// that's how Tendermint represents the absent vote of a validator
+const consensusNilVote = "nil-Vote"
+
+func ConsensusHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.ClientConn, tendermintClient *tmrpc.HTTP) {
+	requestStart := time.Now()
+
+	sublogger := log.With().
+		Str("request-id", uuid.New().String()).
+		Logger()
+
+	consensusHeightGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_consensus_height",
+			Help:        "Height the consensus is currently at",
+			ConstLabels: ConstLabels,
+		},
+	)
+
+	consensusRoundGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_consensus_round",
+			Help:        "Round the consensus is currently at",
+			ConstLabels: ConstLabels,
+		},
+	)
+
+	consensusStepGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_consensus_step",
+			Help:        "Step the consensus is currently at, from 1 (NewHeight) to 8 (Commit)",
+			ConstLabels: ConstLabels,
+		},
+	)
+
+	consensusPrevotesGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_consensus_prevotes",
+			Help:        "Share of voting power that has prevoted in the current round",
+			ConstLabels: ConstLabels,
+		},
+	)
+
+	consensusPrecommitsGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_consensus_precommits",
+			Help:        "Share of voting power that has precommitted in the current round",
+			ConstLabels: ConstLabels,
+		},
+	)
+
+	consensusValidatorPrevotedGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_consensus_validator_prevoted",
+			Help:        "1 if the validator has prevoted in the current round, 0 if no",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker", "consensus_address_hex"},
+	)
+
+	consensusValidatorPrecommittedGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_consensus_validator_precommitted",
+			Help:        "1 if the validator has precommitted in the current round, 0 if no",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker", "consensus_address_hex"},
+	)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(consensusHeightGauge)
+	registry.MustRegister(consensusRoundGauge)
+	registry.MustRegister(consensusStepGauge)
+	registry.MustRegister(consensusPrevotesGauge)
+	registry.MustRegister(consensusPrecommitsGauge)
+	registry.MustRegister(consensusValidatorPrevotedGauge)
+	registry.MustRegister(consensusValidatorPrecommittedGauge)
+
+	var roundState consensusRoundState
+	var roundStateFetched bool
+	var validators map[string]stakingtypes.Validator
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sublogger.Debug().Msg("Started querying consensus state")
+		queryStart := time.Now()
+
+		response, err := tendermintClient.DumpConsensusState(context.Background())
+		if err != nil {
+			sublogger.Error().Err(err).Msg("Could not get consensus state")
+			return
+		}
+
+		sublogger.Debug().
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying consensus state")
+
+		if err := json.Unmarshal(response.RoundState, &roundState); err != nil {
+			sublogger.Error().Err(err).Msg("Could not parse consensus state")
+			return
+		}
+
+		roundStateFetched = true
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sublogger.Debug().Msg("Started querying validators")
+		queryStart := time.Now()
+
+		response, err := getValidatorsByConsensusAddress(grpcConn)
+		if err != nil {
+			sublogger.Error().Err(err).Msg("Could not get validators")
+			return
+		}
+
+		sublogger.Debug().
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying validators")
+		validators = response
+	}()
+
+	wg.Wait()
+
+	if roundStateFetched {
+		if height, err := strconv.ParseFloat(roundState.Height, 64); err != nil {
+			sublogger.Error().
+				Err(err).
+				Msg("Could not parse consensus height")
+		} else {
+			consensusHeightGauge.Set(height)
+		}
+
+		consensusRoundGauge.Set(float64(roundState.Round))
+		consensusStepGauge.Set(float64(roundState.Step))
+
+		var currentRoundVotes *consensusRoundVotes
+		for index := range roundState.Votes {
+			if roundState.Votes[index].Round == roundState.Round {
+				currentRoundVotes = &roundState.Votes[index]
+				break
+			}
+		}
+
+		if currentRoundVotes == nil {
+			sublogger.Warn().
+				Int32("round", roundState.Round).
+				Msg("Could not find votes for the current round")
+		} else {
+			if value, err := parseVotesBitArray(currentRoundVotes.PrevotesBitArray); err != nil {
+				sublogger.Error().
+					Err(err).
+					Msg("Could not parse prevotes")
+			} else {
+				consensusPrevotesGauge.Set(value)
+			}
+
+			if value, err := parseVotesBitArray(currentRoundVotes.PrecommitsBitArray); err != nil {
+				sublogger.Error().
+					Err(err).
+					Msg("Could not parse precommits")
+			} else {
+				consensusPrecommitsGauge.Set(value)
+			}
+
+			// votes are ordered the same way as validators are
+			for index, tendermintValidator := range roundState.Validators.Validators {
+				labels := prometheus.Labels{
+					"address":               "",
+					"moniker":               "",
+					"consensus_address_hex": tendermintValidator.Address,
+				}
+
+				if validator, ok := validators[tendermintValidator.Address]; ok {
+					labels["address"] = validator.OperatorAddress
+					labels["moniker"] = validator.Description.Moniker
+				}
+
+				if index < len(currentRoundVotes.Prevotes) {
+					// golang doesn't have a ternary operator, so we have to stick with this ugly solution
+					var prevoted float64
+
+					if currentRoundVotes.Prevotes[index] != consensusNilVote {
+						prevoted = 1
+					} else {
+						prevoted = 0
+					}
+
+					consensusValidatorPrevotedGauge.With(labels).Set(prevoted)
+				}
+
+				if index < len(currentRoundVotes.Precommits) {
+					var precommitted float64
+
+					if currentRoundVotes.Precommits[index] != consensusNilVote {
+						precommitted = 1
+					} else {
+						precommitted = 0
+					}
+
+					consensusValidatorPrecommittedGauge.With(labels).Set(precommitted)
+				}
+			}
+		}
+	}
+
+	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	h.ServeHTTP(w, r)
+	sublogger.Info().
+		Str("method", "GET").
+		Str("endpoint", "/metrics/consensus").
+		Float64("request-time", time.Since(requestStart).Seconds()).
+		Msg("Request processed")
+}
+
+// parseVotesBitArray takes the voted share of the voting power out of the string
+// like "BA{4:xx_x} 30/40 = 0.75", as Tendermint rounds it to 2 digits itself.
+func parseVotesBitArray(bitArray string) (float64, error) {
+	parts := strings.Split(bitArray, " ")
+	if len(parts) < 2 {
+		return 0, fmt.Errorf("unexpected votes bit array format: %s", bitArray)
+	}
+
+	var voted, total int64
+	if _, err := fmt.Sscanf(parts[1], "%d/%d", &voted, &total); err != nil {
+		return 0, err
+	}
+
+	if total == 0 {
+		return 0, nil
+	}
+
+	return float64(voted) / float64(total), nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseVotesBitArray(t *testing.T) {
+	tests := []struct {
+		name     string
+		bitArray string
+		expected float64
+		err      bool
+	}{
+		{"partially voted", "BA{4:xx_x} 30/40 = 0.75", 0.75, false},
+		{"not rounded", "BA{3:x__} 1/3 = 0.33", 1.0 / 3, false},
+		{"all voted", "BA{2:xx} 20/20 = 1.00", 1, false},
+		{"nobody voted", "BA{2:__} 0/20 = 0.00", 0, false},
+		{"zero voting power", "BA{0:} 0/0 = 0.00", 0, false},
+		{"no votes part", "BA{2:xx}", 0, true},
+		{"malformed votes", "BA{2:xx} a/b = 0.00", 0, true},
+		{"empty", "", 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := parseVotesBitArray(test.bitArray)
+			if test.err {
+				if err == nil {
+					t.Errorf("expected an error, got %f", actual)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if math.Abs(actual-test.expected) > 1e-9 {
+				t.Errorf("expected %f, got %f", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"google.golang.org/grpc"
+)
+
+// BondDenom is the base denom the chain returns the --denom coins in.
+var BondDenom string
+
+// setBondDenom gets the base denom of the --denom, so the coins from the chain can be converted.
+// It's not fatal if the node doesn't return it, just the coins won't be converted.
+func setBondDenom(grpcConn *grpc.ClientConn) {
+	stakingClient := stakingtypes.NewQueryClient(grpcConn)
+	params, err := stakingClient.Params(
+		context.Background(),
+		&stakingtypes.QueryParamsRequest{},
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("Could not get bond denom, coins amounts won't be converted")
+		return
+	}
+
+	BondDenom = params.Params.BondDenom
+	log.Info().Str("bond-denom", BondDenom).Msg("Got bond denom")
+}
+
+// getCoinValue converts the coin amount from the chain into the --denom display units,
+// only the bond denom is converted, as the decimals of the other denoms are unknown.
+func getCoinValue(denom string, amount float64) (string, float64) {
+	if denom == BondDenom {
+		return Denom, amount / DenomCoefficient
+	}
+
+	return denom, amount
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/simapp"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	querytypes "github.com/cosmos/cosmos-sdk/types/query"
+	evidenceexported "github.com/cosmos/cosmos-sdk/x/evidence/exported"
+	evidencetypes "github.com/cosmos/cosmos-sdk/x/evidence/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+)
+
+// EvidenceCounter remembers the evidence seen in the previous scrapes to count the new one.
+// The evidence existing at the first scrape is not counted, otherwise each exporter restart
+// would look like new evidence appeared.
+type EvidenceCounter struct {
+	mutex       sync.Mutex
+	seen        map[string]bool
+	initialized bool
+	counter     prometheus.Counter
+}
+
+func NewEvidenceCounter() *EvidenceCounter {
+	return &EvidenceCounter{
+		seen: make(map[string]bool),
+		counter: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name:        "cosmos_evidence_new_total",
+				Help:        "Amount of new evidence seen since the exporter start",
+				ConstLabels: ConstLabels,
+			},
+		),
+	}
+}
+
+func (c *EvidenceCounter) observe(hashes []string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, hash := range hashes {
+		if c.seen[hash] {
+			continue
+		}
+
+		c.seen[hash] = true
+		if c.initialized {
+			c.counter.Inc()
+		}
+	}
+
+	c.initialized = true
+}
+
+func EvidenceHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.ClientConn, evidenceCounter *EvidenceCounter) {
+	encCfg := simapp.MakeTestEncodingConfig()
+	interfaceRegistry := encCfg.InterfaceRegistry
+
+	requestStart := time.Now()
+
+	sublogger := log.With().
+		Str("request-id", uuid.New().String()).
+		Logger()
+
+	evidenceEquivocationsGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_evidence_equivocations",
+			Help:        "Amount of double sign evidence against the validator",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"consensus_address", "address", "moniker"},
+	)
+
+	evidenceEquivocationHeightGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_evidence_equivocation_height",
+			Help:        "Height of the latest double sign by the validator",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"consensus_address", "address", "moniker"},
+	)
+
+	evidenceEquivocationTimeGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_evidence_equivocation_time",
+			Help:        "Time of the latest double sign by the validator, in unix seconds",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"consensus_address", "address", "moniker"},
+	)
+
+	evidenceEquivocationPowerGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_evidence_equivocation_power",
+			Help:        "Voting power of the validator at the latest double sign",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"consensus_address", "address", "moniker"},
+	)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(evidenceEquivocationsGauge)
+	registry.MustRegister(evidenceEquivocationHeightGauge)
+	registry.MustRegister(evidenceEquivocationTimeGauge)
+	registry.MustRegister(evidenceEquivocationPowerGauge)
+	registry.MustRegister(evidenceCounter.counter)
+
+	var equivocations []*evidencetypes.Equivocation
+	var validators map[string]stakingtypes.Validator
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sublogger.Debug().Msg("Started querying evidence")
+		queryStart := time.Now()
+
+		evidenceClient := evidencetypes.NewQueryClient(grpcConn)
+
+		// evidence is stored by hash, not by time, so the new one can be on any page
+		var evidenceAnys []*codectypes.Any
+		var nextKey []byte
+
+		for {
+			evidenceResponse, err := evidenceClient.AllEvidence(
+				context.Background(),
+				&evidencetypes.QueryAllEvidenceRequest{
+					Pagination: &querytypes.PageRequest{
+						Key:   nextKey,
+						Limit: Limit,
+					},
+				},
+			)
+			if err != nil {
+				sublogger.Error().Err(err).Msg("Could not get evidence")
+				return
+			}
+
+			evidenceAnys = append(evidenceAnys, evidenceResponse.Evidence...)
+
+			if evidenceResponse.Pagination == nil || len(evidenceResponse.Pagination.NextKey) == 0 {
+				break
+			}
+
+			nextKey = evidenceResponse.Pagination.NextKey
+		}
+
+		sublogger.Debug().
+			Int("evidence", len(evidenceAnys)).
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying evidence")
+
+		hashes := make([]string, 0, len(evidenceAnys))
+
+		for _, evidenceAny := range evidenceAnys {
+			var evidence evidenceexported.Evidence
+			if err := interfaceRegistry.UnpackAny(evidenceAny, &evidence); err != nil {
+				sublogger.Error().
+					Str("type", evidenceAny.TypeUrl).
+					Err(err).
+					Msg("Could not unpack evidence")
+				continue
+			}
+
+			hashes = append(hashes, evidence.Hash().String())
+
+			if equivocation, ok := evidence.(*evidencetypes.Equivocation); ok {
+				equivocations = append(equivocations, equivocation)
+			} else {
+				sublogger.Debug().
+					Str("type", evidenceAny.TypeUrl).
+					Msg("Skipping unsupported evidence type")
+			}
+		}
+
+		evidenceCounter.observe(hashes)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sublogger.Debug().Msg("Started querying validators")
+		queryStart := time.Now()
+
+		response, err := getValidatorsByConsensusAddress(grpcConn)
+		if err != nil {
+			sublogger.Error().Err(err).Msg("Could not get validators")
+			return
+		}
+
+		sublogger.Debug().
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying validators")
+		validators = response
+	}()
+
+	wg.Wait()
+
+	equivocationsCount := make(map[string]int)
+	latestEquivocations := make(map[string]*evidencetypes.Equivocation)
+
+	for _, equivocation := range equivocations {
+		equivocationsCount[equivocation.ConsensusAddress]++
+
+		if latest, ok := latestEquivocations[equivocation.ConsensusAddress]; !ok || equivocation.Height > latest.Height {
+			latestEquivocations[equivocation.ConsensusAddress] = equivocation
+		}
+	}
+
+	for consensusAddress, equivocation := range latestEquivocations {
+		labels := prometheus.Labels{
+			"consensus_address": consensusAddress,
+			"address":           "",
+			"moniker":           "",
+		}
+
+		// the validator might be not in the list if it was removed after being tombstoned
+		if consAddress, err := sdk.ConsAddressFromBech32(consensusAddress); err != nil {
+			sublogger.Error().
+				Str("address", consensusAddress).
+				Err(err).
+				Msg("Could not parse consensus address")
+		} else if validator, ok := validators[strings.ToUpper(hex.EncodeToString(consAddress))]; ok {
+			labels["address"] = validator.OperatorAddress
+			labels["moniker"] = validator.Description.Moniker
+		}
+
+		evidenceEquivocationsGauge.With(labels).Set(float64(equivocationsCount[consensusAddress]))
+		evidenceEquivocationHeightGauge.With(labels).Set(float64(equivocation.Height))
+		evidenceEquivocationTimeGauge.With(labels).Set(float64(equivocation.Time.Unix()))
+		evidenceEquivocationPowerGauge.With(labels).Set(float64(equivocation.Power))
+	}
+
+	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	h.ServeHTTP(w, r)
+	sublogger.Info().
+		Str("method", "GET").
+		Str("endpoint", "/metrics/evidence").
+		Float64("request-time", time.Since(requestStart).Seconds()).
+		Msg("Request processed")
+}
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	querytypes "github.com/cosmos/cosmos-sdk/types/query"
+	gogotypes "github.com/gogo/protobuf/types"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+)
+
+// see cosmos/feegrant/v1beta1/query.proto and feegrant.proto
+
+type feegrantAllowancesRequest struct {
+	Grantee    string                  `protobuf:"bytes,1,opt,name=grantee,proto3"`
+	Pagination *querytypes.PageRequest `protobuf:"bytes,2,opt,name=pagination,proto3"`
+}
+
+func (m *feegrantAllowancesRequest) Reset()         { *m = feegrantAllowancesRequest{} }
+func (m *feegrantAllowancesRequest) String() string { return proto.CompactTextString(m) }
+func (*feegrantAllowancesRequest) ProtoMessage()    {}
+
+type feegrantAllowancesByGranterRequest struct {
+	Granter    string                  `protobuf:"bytes,1,opt,name=granter,proto3"`
+	Pagination *querytypes.PageRequest `protobuf:"bytes,2,opt,name=pagination,proto3"`
+}
+
+func (m *feegrantAllowancesByGranterRequest) Reset()         { *m = feegrantAllowancesByGranterRequest{} }
+func (m *feegrantAllowancesByGranterRequest) String() string { return proto.CompactTextString(m) }
+func (*feegrantAllowancesByGranterRequest) ProtoMessage()    {}
+
+// both Allowances and AllowancesByGranter return the same response
+type feegrantAllowancesResponse struct {
+	Allowances []*feegrantGrant         `protobuf:"bytes,1,rep,name=allowances,proto3"`
+	Pagination *querytypes.PageResponse `protobuf:"bytes,2,opt,name=pagination,proto3"`
+}
+
+func (m *feegrantAllowancesResponse) Reset()         { *m = feegrantAllowancesResponse{} }
+func (m *feegrantAllowancesResponse) String() string { return proto.CompactTextString(m) }
+func (*feegrantAllowancesResponse) ProtoMessage()    {}
+
+type feegrantGrant struct {
+	Granter   string          `protobuf:"bytes,1,opt,name=granter,proto3"`
+	Grantee   string          `protobuf:"bytes,2,opt,name=grantee,proto3"`
+	Allowance *codectypes.Any `protobuf:"bytes,3,opt,name=allowance,proto3"`
+}
+
+func (m *feegrantGrant) Reset()         { *m = feegrantGrant{} }
+func (m *feegrantGrant) String() string { return proto.CompactTextString(m) }
+func (*feegrantGrant) ProtoMessage()    {}
+
+type feegrantBasicAllowance struct {
+	SpendLimit []*protoCoin         `protobuf:"bytes,1,rep,name=spend_limit,proto3"`
+	Expiration *gogotypes.Timestamp `protobuf:"bytes,2,opt,name=expiration,proto3"`
+}
+
+func (m *feegrantBasicAllowance) Reset()         { *m = feegrantBasicAllowance{} }
+func (m *feegrantBasicAllowance) String() string { return proto.CompactTextString(m) }
+func (*feegrantBasicAllowance) ProtoMessage()    {}
+
+// the period itself and its spend limit are omitted
+type feegrantPeriodicAllowance struct {
+	Basic          *feegrantBasicAllowance `protobuf:"bytes,1,opt,name=basic,proto3"`
+	PeriodCanSpend []*protoCoin            `protobuf:"bytes,4,rep,name=period_can_spend,proto3"`
+	PeriodReset    *gogotypes.Timestamp    `protobuf:"bytes,5,opt,name=period_reset,proto3"`
+}
+
+func (m *feegrantPeriodicAllowance) Reset()         { *m = feegrantPeriodicAllowance{} }
+func (m *feegrantPeriodicAllowance) String() string { return proto.CompactTextString(m) }
+func (*feegrantPeriodicAllowance) ProtoMessage()    {}
+
+// it wraps another allowance, restricting the messages it can be used for
+type feegrantAllowedMsgAllowance struct {
+	Allowance *codectypes.Any `protobuf:"bytes,1,opt,name=allowance,proto3"`
+}
+
+func (m *feegrantAllowedMsgAllowance) Reset()         { *m = feegrantAllowedMsgAllowance{} }
+func (m *feegrantAllowedMsgAllowance) String() string { return proto.CompactTextString(m) }
+func (*feegrantAllowedMsgAllowance) ProtoMessage()    {}
+
+const (
+	feegrantBasicAllowanceType      = "/cosmos.feegrant.v1beta1.BasicAllowance"
+	feegrantPeriodicAllowanceType   = "/cosmos.feegrant.v1beta1.PeriodicAllowance"
+	feegrantAllowedMsgAllowanceType = "/cosmos.feegrant.v1beta1.AllowedMsgAllowance"
+)
+
+func FeegrantHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.ClientConn) {
+	requestStart := time.Now()
+
+	sublogger := log.With().
+		Str("request-id", uuid.New().String()).
+		Logger()
+
+	address := r.URL.Query().Get("address")
+	myAddress, err := sdk.AccAddressFromBech32(address)
+	if err != nil {
+		sublogger.Error().
+			Str("address", address).
+			Err(err).
+			Msg("Could not get address")
+		return
+	}
+
+	feegrantAllowanceGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_feegrant_allowance",
+			Help:        "Fee allowance of the Cosmos-based blockchain wallet, always 1",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"granter", "grantee", "allowance_type"},
+	)
+
+	feegrantSpendLimitGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_feegrant_spend_limit",
+			Help:        "Amount left to spend with the fee allowance of the Cosmos-based blockchain wallet",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"granter", "grantee", "denom"},
+	)
+
+	feegrantPeriodCanSpendGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_feegrant_period_can_spend",
+			Help:        "Amount left to spend in the current period with the periodic fee allowance of the Cosmos-based blockchain wallet",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"granter", "grantee", "denom"},
+	)
+
+	feegrantPeriodResetGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_feegrant_period_reset",
+			Help:        "Time the current period of the periodic fee allowance of the Cosmos-based blockchain wallet ends at, in unix seconds",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"granter", "grantee"},
+	)
+
+	feegrantExpirationGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_feegrant_expiration",
+			Help:        "Expiration time of the fee allowance of the Cosmos-based blockchain wallet, in unix seconds",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"granter", "grantee"},
+	)
+
+	feegrantExpirationRemainingGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_feegrant_expiration_remaining",
+			Help:        "Seconds left until the fee allowance of the Cosmos-based blockchain wallet expires, negative if it has expired",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"granter", "grantee"},
+	)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(feegrantAllowanceGauge)
+	registry.MustRegister(feegrantSpendLimitGauge)
+	registry.MustRegister(feegrantPeriodCanSpendGauge)
+	registry.MustRegister(feegrantPeriodResetGauge)
+	registry.MustRegister(feegrantExpirationGauge)
+	registry.MustRegister(feegrantExpirationRemainingGauge)
+
+	var mutex sync.Mutex
+	var grants []*feegrantGrant
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sublogger.Debug().
+			Str("address", address).
+			Msg("Started querying allowances by grantee")
+		queryStart := time.Now()
+
+		var pageGrants []*feegrantGrant
+		var nextKey []byte
+
+		for {
+			response := &feegrantAllowancesResponse{}
+			err := grpcConn.Invoke(
+				context.Background(),
+				"/cosmos.feegrant.v1beta1.Query/Allowances",
+				&feegrantAllowancesRequest{
+					Grantee: myAddress.String(),
+					Pagination: &querytypes.PageRequest{
+						Key:   nextKey,
+						Limit: Limit,
+					},
+				},
+				response,
+			)
+			if err != nil {
+				sublogger.Error().
+					Str("address", address).
+					Err(err).
+					Msg("Could not get allowances by grantee")
+				return
+			}
+
+			pageGrants = append(pageGrants, response.Allowances...)
+
+			if response.Pagination == nil || len(response.Pagination.NextKey) == 0 {
+				break
+			}
+
+			nextKey = response.Pagination.NextKey
+		}
+
+		sublogger.Debug().
+			Str("address", address).
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying allowances by grantee")
+
+		mutex.Lock()
+		grants = append(grants, pageGrants...)
+		mutex.Unlock()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sublogger.Debug().
+			Str("address", address).
+			Msg("Started querying allowances by granter")
+		queryStart := time.Now()
+
+		var pageGrants []*feegrantGrant
+		var nextKey []byte
+
+		for {
+			response := &feegrantAllowancesResponse{}
+			err := grpcConn.Invoke(
+				context.Background(),
+				"/cosmos.feegrant.v1beta1.Query/AllowancesByGranter",
+				&feegrantAllowancesByGranterRequest{
+					Granter: myAddress.String(),
+					Pagination: &querytypes.PageRequest{
+						Key:   nextKey,
+						Limit: Limit,
+					},
+				},
+				response,
+			)
+			if err != nil {
+				sublogger.Error().
+					Str("address", address).
+					Err(err).
+					Msg("Could not get allowances by granter")
+				return
+			}
+
+			pageGrants = append(pageGrants, response.Allowances...)
+
+			if response.Pagination == nil || len(response.Pagination.NextKey) == 0 {
+				break
+			}
+
+			nextKey = response.Pagination.NextKey
+		}
+
+		sublogger.Debug().
+			Str("address", address).
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying allowances by granter")
+
+		mutex.Lock()
+		grants = append(grants, pageGrants...)
+		mutex.Unlock()
+	}()
+
+	wg.Wait()
+
+	for _, grant := range grants {
+		if grant.Allowance == nil {
+			continue
+		}
+
+		allowance := grant.Allowance
+
+		// only the wrapped allowance has the limits
+		if allowance.TypeUrl == feegrantAllowedMsgAllowanceType {
+			allowedMsgAllowance := &feegrantAllowedMsgAllowance{}
+			if err := proto.Unmarshal(allowance.Value, allowedMsgAllowance); err != nil || allowedMsgAllowance.Allowance == nil {
+				sublogger.Error().
+					Str("address", address).
+					Err(err).
+					Msg("Could not parse allowed messages allowance")
+				continue
+			}
+
+			allowance = allowedMsgAllowance.Allowance
+		}
+
+		var basicAllowance *feegrantBasicAllowance
+
+		switch allowance.TypeUrl {
+		case feegrantBasicAllowanceType:
+			basicAllowance = &feegrantBasicAllowance{}
+			if err := proto.Unmarshal(allowance.Value, basicAllowance); err != nil {
+				sublogger.Error().
+					Str("address", address).
+					Err(err).
+					Msg("Could not parse basic allowance")
+				continue
+			}
+
+			feegrantAllowanceGauge.With(prometheus.Labels{
+				"granter":        grant.Granter,
+				"grantee":        grant.Grantee,
+				"allowance_type": "basic",
+			}).Set(1)
+		case feegrantPeriodicAllowanceType:
+			periodicAllowance := &feegrantPeriodicAllowance{}
+			if err := proto.Unmarshal(allowance.Value, periodicAllowance); err != nil {
+				sublogger.Error().
+					Str("address", address).
+					Err(err).
+					Msg("Could not parse periodic allowance")
+				continue
+			}
+
+			feegrantAllowanceGauge.With(prometheus.Labels{
+				"granter":        grant.Granter,
+				"grantee":        grant.Grantee,
+				"allowance_type": "periodic",
+			}).Set(1)
+
+			setFeegrantCoins(feegrantPeriodCanSpendGauge, grant, periodicAllowance.PeriodCanSpend, sublogger)
+
+			if periodicAllowance.PeriodReset != nil {
+				if periodReset, err := gogotypes.TimestampFromProto(periodicAllowance.PeriodReset); err != nil {
+					sublogger.Error().
+						Str("address", address).
+						Err(err).
+						Msg("Could not parse allowance period reset")
+				} else {
+					feegrantPeriodResetGauge.With(prometheus.Labels{
+						"granter": grant.Granter,
+						"grantee": grant.Grantee,
+					}).Set(float64(periodReset.Unix()))
+				}
+			}
+
+			basicAllowance = periodicAllowance.Basic
+		default:
+			sublogger.Debug().
+				Str("address", address).
+				Str("type", allowance.TypeUrl).
+				Msg("Unsupported allowance type, not exporting its details")
+
+			feegrantAllowanceGauge.With(prometheus.Labels{
+				"granter":        grant.Granter,
+				"grantee":        grant.Grantee,
+				"allowance_type": allowance.TypeUrl,
+			}).Set(1)
+		}
+
+		if basicAllowance == nil {
+			continue
+		}
+
+		// allowances without the spend limit are unlimited
+		setFeegrantCoins(feegrantSpendLimitGauge, grant, basicAllowance.SpendLimit, sublogger)
+
+		// allowances without expiration never expire
+		if basicAllowance.Expiration == nil {
+			continue
+		}
+
+		expiration, err := gogotypes.TimestampFromProto(basicAllowance.Expiration)
+		if err != nil {
+			sublogger.Error().
+				Str("address", address).
+				Err(err).
+				Msg("Could not parse allowance expiration")
+			continue
+		}
+
+		feegrantExpirationGauge.With(prometheus.Labels{
+			"granter": grant.Granter,
+			"grantee": grant.Grantee,
+		}).Set(float64(expiration.Unix()))
+
+		feegrantExpirationRemainingGauge.With(prometheus.Labels{
+			"granter": grant.Granter,
+			"grantee": grant.Grantee,
+		}).Set(time.Until(expiration).Seconds())
+	}
+
+	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	h.ServeHTTP(w, r)
+	sublogger.Info().
+		Str("method", "GET").
+		Str("endpoint", "/metrics/feegrant?address="+address).
+		Float64("request-time", time.Since(requestStart).Seconds()).
+		Msg("Request processed")
+}
+
+func setFeegrantCoins(gauge *prometheus.GaugeVec, grant *feegrantGrant, coins []*protoCoin, sublogger zerolog.Logger) {
+	for _, coin := range coins {
+		if value, err := strconv.ParseFloat(coin.Amount, 64); err != nil {
+			sublogger.Error().
+				Str("granter", grant.Granter).
+				Str("grantee", grant.Grantee).
+				Err(err).
+				Msg("Could not parse allowance coin")
+		} else {
+			denom, value := getCoinValue(coin.Denom, value)
+			gauge.With(prometheus.Labels{
+				"granter": grant.Granter,
+				"grantee": grant.Grantee,
+				"denom":   denom,
+			}).Set(value)
+		}
+	}
+}
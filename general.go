@@ -2,18 +2,27 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	querytypes "github.com/cosmos/cosmos-sdk/types/query"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 	distributiontypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types"
+	transfertypes "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/types"
+	channeltypes "github.com/cosmos/cosmos-sdk/x/ibc/core/04-channel/types"
 	minttypes "github.com/cosmos/cosmos-sdk/x/mint/types"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
 	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog"
 	"google.golang.org/grpc"
 )
 
@@ -75,6 +84,100 @@ func GeneralHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Clien
 		[]string{"denom"},
 	)
 
+	generalStakingAPRGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_general_staking_apr",
+			Help:        "Nominal staking APR before validators commission",
+			ConstLabels: ConstLabels,
+		},
+	)
+
+	generalBondedRatioGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_general_bonded_ratio",
+			Help:        "Bonded tokens as a fraction of the bond denom total supply",
+			ConstLabels: ConstLabels,
+		},
+	)
+
+	generalNakamotoCoefficientGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_general_nakamoto_coefficient",
+			Help:        "Minimal amount of validators having more voting power than the threshold",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"threshold"},
+	)
+
+	generalVotingPowerGiniGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_general_voting_power_gini",
+			Help:        "Gini coefficient of the active validators voting power",
+			ConstLabels: ConstLabels,
+		},
+	)
+
+	generalActiveValidatorsGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_general_active_validators",
+			Help:        "Amount of validators in the active set",
+			ConstLabels: ConstLabels,
+		},
+	)
+
+	generalMaxValidatorsGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_general_max_validators",
+			Help:        "Active set length",
+			ConstLabels: ConstLabels,
+		},
+	)
+
+	generalValidatorsGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_general_validators",
+			Help:        "Amount of validators by status",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"status"},
+	)
+
+	generalModuleAccountBalanceGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_general_module_account_balance",
+			Help:        "Balance of the module account",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"module", "address", "denom"},
+	)
+
+	generalCirculatingSupplyGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_general_circulating_supply",
+			Help:        "Bond denom total supply minus the balances of --non-circulating-addresses",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"denom"},
+	)
+
+	generalNonCirculatingBalanceGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_general_non_circulating_balance",
+			Help:        "Bond denom balance of the address excluded from the circulating supply",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "denom"},
+	)
+
+	generalIBCEscrowGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_general_ibc_escrow",
+			Help:        "Native tokens escrowed in the IBC transfer channel, i.e. sent to the other chain",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"port", "channel", "counterparty_channel", "address", "denom"},
+	)
+
 	registry := prometheus.NewRegistry()
 	registry.MustRegister(generalBondedTokensGauge)
 	registry.MustRegister(generalNotBondedTokensGauge)
@@ -82,6 +185,17 @@ func GeneralHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Clien
 	registry.MustRegister(generalSupplyTotalGauge)
 	registry.MustRegister(generalInflationGauge)
 	registry.MustRegister(generalAnnualProvisions)
+	registry.MustRegister(generalStakingAPRGauge)
+	registry.MustRegister(generalBondedRatioGauge)
+	registry.MustRegister(generalNakamotoCoefficientGauge)
+	registry.MustRegister(generalVotingPowerGiniGauge)
+	registry.MustRegister(generalActiveValidatorsGauge)
+	registry.MustRegister(generalMaxValidatorsGauge)
+	registry.MustRegister(generalValidatorsGauge)
+	registry.MustRegister(generalModuleAccountBalanceGauge)
+	registry.MustRegister(generalCirculatingSupplyGauge)
+	registry.MustRegister(generalNonCirculatingBalanceGauge)
+	registry.MustRegister(generalIBCEscrowGauge)
 
 	var wg sync.WaitGroup
 
@@ -135,9 +249,10 @@ func GeneralHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Clien
 					Err(err).
 					Msg("Could not get community pool coin")
 			} else {
+				denom, value := getCoinValue(coin.Denom, value)
 				generalCommunityPoolGauge.With(prometheus.Labels{
-					"denom": Denom,
-				}).Set(value / DenomCoefficient)
+					"denom": denom,
+				}).Set(value)
 			}
 		}
 	}()
@@ -163,18 +278,169 @@ func GeneralHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Clien
 			Msg("Finished querying bank total supply")
 
 		for _, coin := range response.Supply {
+			// the bond denom supply is taken from SupplyOf below
+			if coin.Denom == BondDenom {
+				continue
+			}
+
 			if value, err := strconv.ParseFloat(coin.Amount.String(), 64); err != nil {
 				sublogger.Error().
 					Err(err).
 					Msg("Could not get total supply")
 			} else {
+				denom, value := getCoinValue(coin.Denom, value)
 				generalSupplyTotalGauge.With(prometheus.Labels{
-					"denom": Denom,
-				}).Set(value / DenomCoefficient)
+					"denom": denom,
+				}).Set(value)
 			}
 		}
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		// the total supply is paginated on newer chains and the bond denom might be not
+		// on the first page, so it's queried separately
+		if BondDenom == "" {
+			return
+		}
+
+		sublogger.Debug().Msg("Started querying bond denom supply")
+		queryStart := time.Now()
+
+		bankClient := banktypes.NewQueryClient(grpcConn)
+		response, err := bankClient.SupplyOf(
+			context.Background(),
+			&banktypes.QuerySupplyOfRequest{Denom: BondDenom},
+		)
+		if err != nil {
+			sublogger.Error().Err(err).Msg("Could not get bond denom supply")
+			return
+		}
+
+		sublogger.Debug().
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying bond denom supply")
+
+		supply, err := strconv.ParseFloat(response.Amount.Amount.String(), 64)
+		if err != nil {
+			sublogger.Error().Err(err).Msg("Could not parse bond denom supply")
+			return
+		}
+
+		denom, value := getCoinValue(BondDenom, supply)
+		generalSupplyTotalGauge.With(prometheus.Labels{
+			"denom": denom,
+		}).Set(value)
+
+		nonCirculating, err := getNonCirculatingBalances(grpcConn, sublogger, generalNonCirculatingBalanceGauge)
+		if err != nil {
+			sublogger.Error().Err(err).Msg("Could not get non-circulating balances")
+			return
+		}
+
+		denom, value = getCoinValue(BondDenom, supply-nonCirculating)
+		generalCirculatingSupplyGauge.With(prometheus.Labels{
+			"denom": denom,
+		}).Set(value)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sublogger.Debug().Msg("Started querying IBC transfer channels")
+		queryStart := time.Now()
+
+		channelClient := channeltypes.NewQueryClient(grpcConn)
+
+		var channels []*channeltypes.IdentifiedChannel
+		var nextKey []byte
+
+		for {
+			response, err := channelClient.Channels(
+				context.Background(),
+				&channeltypes.QueryChannelsRequest{
+					Pagination: &querytypes.PageRequest{
+						Key:   nextKey,
+						Limit: Limit,
+					},
+				},
+			)
+			if err != nil {
+				sublogger.Error().Err(err).Msg("Could not get IBC channels")
+				return
+			}
+
+			channels = append(channels, response.Channels...)
+
+			if response.Pagination == nil || len(response.Pagination.NextKey) == 0 {
+				break
+			}
+
+			nextKey = response.Pagination.NextKey
+		}
+
+		sublogger.Debug().
+			Int("channels", len(channels)).
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying IBC transfer channels")
+
+		var channelsWg sync.WaitGroup
+
+		for _, channel := range channels {
+			if channel.PortId != transfertypes.PortID {
+				continue
+			}
+
+			channelsWg.Add(1)
+			go func(channel *channeltypes.IdentifiedChannel) {
+				defer channelsWg.Done()
+
+				escrowAddress := transfertypes.GetEscrowAddress(channel.PortId, channel.ChannelId).String()
+
+				bankClient := banktypes.NewQueryClient(grpcConn)
+				balancesResponse, err := bankClient.AllBalances(
+					context.Background(),
+					&banktypes.QueryAllBalancesRequest{Address: escrowAddress},
+				)
+				if err != nil {
+					sublogger.Error().
+						Str("channel", channel.ChannelId).
+						Err(err).
+						Msg("Could not get IBC escrow balance")
+					return
+				}
+
+				for _, coin := range balancesResponse.Balances {
+					// vouchers of other chains are escrowed too if sent further to a third chain,
+					// but they are not part of this chain supply
+					if strings.HasPrefix(coin.Denom, "ibc/") {
+						continue
+					}
+
+					if value, err := strconv.ParseFloat(coin.Amount.String(), 64); err != nil {
+						sublogger.Error().
+							Str("channel", channel.ChannelId).
+							Err(err).
+							Msg("Could not parse IBC escrow balance")
+					} else {
+						denom, value := getCoinValue(coin.Denom, value)
+						generalIBCEscrowGauge.With(prometheus.Labels{
+							"port":                 channel.PortId,
+							"channel":              channel.ChannelId,
+							"counterparty_channel": channel.Counterparty.ChannelId,
+							"address":              escrowAddress,
+							"denom":                denom,
+						}).Set(value)
+					}
+				}
+			}(channel)
+		}
+
+		channelsWg.Wait()
+	}()
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -235,6 +501,232 @@ func GeneralHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Clien
 		}
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sublogger.Debug().Msg("Started calculating staking APR")
+		queryStart := time.Now()
+
+		apr, err := APRSource.GetStakingAPR(grpcConn)
+		if err != nil {
+			sublogger.Error().Err(err).Msg("Could not calculate staking APR")
+			return
+		}
+
+		sublogger.Debug().
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished calculating staking APR")
+
+		generalStakingAPRGauge.Set(apr)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sublogger.Debug().Msg("Started querying bonded ratio")
+		queryStart := time.Now()
+
+		stakingClient := stakingtypes.NewQueryClient(grpcConn)
+		paramsResponse, err := stakingClient.Params(
+			context.Background(),
+			&stakingtypes.QueryParamsRequest{},
+		)
+		if err != nil {
+			sublogger.Error().Err(err).Msg("Could not get staking params")
+			return
+		}
+
+		poolResponse, err := stakingClient.Pool(
+			context.Background(),
+			&stakingtypes.QueryPoolRequest{},
+		)
+		if err != nil {
+			sublogger.Error().Err(err).Msg("Could not get staking pool")
+			return
+		}
+
+		bankClient := banktypes.NewQueryClient(grpcConn)
+		supplyResponse, err := bankClient.SupplyOf(
+			context.Background(),
+			&banktypes.QuerySupplyOfRequest{Denom: paramsResponse.Params.BondDenom},
+		)
+		if err != nil {
+			sublogger.Error().Err(err).Msg("Could not get bond denom supply")
+			return
+		}
+
+		sublogger.Debug().
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying bonded ratio")
+
+		bondedTokens, err := strconv.ParseFloat(poolResponse.Pool.BondedTokens.String(), 64)
+		if err != nil {
+			sublogger.Error().Err(err).Msg("Could not parse bonded tokens")
+			return
+		}
+
+		supply, err := strconv.ParseFloat(supplyResponse.Amount.Amount.String(), 64)
+		if err != nil {
+			sublogger.Error().Err(err).Msg("Could not parse bond denom supply")
+			return
+		}
+
+		if supply != 0 {
+			generalBondedRatioGauge.Set(bondedTokens / supply)
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sublogger.Debug().Msg("Started querying validators")
+		queryStart := time.Now()
+
+		stakingClient := stakingtypes.NewQueryClient(grpcConn)
+		response, err := stakingClient.Validators(
+			context.Background(),
+			&stakingtypes.QueryValidatorsRequest{
+				Pagination: &querytypes.PageRequest{
+					Limit: Limit,
+				},
+			},
+		)
+		if err != nil {
+			sublogger.Error().Err(err).Msg("Could not get validators")
+			return
+		}
+
+		sublogger.Debug().
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying validators")
+
+		validatorsByStatus := map[string]int{
+			"bonded":    0,
+			"unbonding": 0,
+			"unbonded":  0,
+		}
+
+		var votingPowers []float64
+
+		for _, validator := range response.Validators {
+			switch validator.Status {
+			case stakingtypes.Bonded:
+				validatorsByStatus["bonded"]++
+			case stakingtypes.Unbonding:
+				validatorsByStatus["unbonding"]++
+			case stakingtypes.Unbonded:
+				validatorsByStatus["unbonded"]++
+			}
+
+			// only bonded validators have voting power
+			if validator.Status != stakingtypes.Bonded {
+				continue
+			}
+
+			if value, err := strconv.ParseFloat(validator.Tokens.String(), 64); err != nil {
+				sublogger.Error().
+					Str("address", validator.OperatorAddress).
+					Err(err).
+					Msg("Could not parse validator tokens")
+			} else {
+				votingPowers = append(votingPowers, value)
+			}
+		}
+
+		for status, count := range validatorsByStatus {
+			generalValidatorsGauge.With(prometheus.Labels{
+				"status": status,
+			}).Set(float64(count))
+		}
+
+		generalActiveValidatorsGauge.Set(float64(validatorsByStatus["bonded"]))
+
+		if len(votingPowers) == 0 {
+			sublogger.Warn().Msg("No bonded validators, not calculating decentralization metrics")
+			return
+		}
+
+		generalNakamotoCoefficientGauge.With(prometheus.Labels{
+			"threshold": "1/3",
+		}).Set(float64(getNakamotoCoefficient(votingPowers, 1.0/3)))
+		generalNakamotoCoefficientGauge.With(prometheus.Labels{
+			"threshold": "2/3",
+		}).Set(float64(getNakamotoCoefficient(votingPowers, 2.0/3)))
+		generalVotingPowerGiniGauge.Set(getGiniCoefficient(votingPowers))
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sublogger.Debug().Msg("Started querying staking params")
+		queryStart := time.Now()
+
+		stakingClient := stakingtypes.NewQueryClient(grpcConn)
+		response, err := stakingClient.Params(
+			context.Background(),
+			&stakingtypes.QueryParamsRequest{},
+		)
+		if err != nil {
+			sublogger.Error().Err(err).Msg("Could not get staking params")
+			return
+		}
+
+		sublogger.Debug().
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying staking params")
+
+		generalMaxValidatorsGauge.Set(float64(response.Params.MaxValidators))
+	}()
+
+	for _, module := range getModuleAccounts() {
+		wg.Add(1)
+		go func(module string) {
+			defer wg.Done()
+
+			// module accounts addresses are derived from their names, so no need to query them
+			moduleAddress := authtypes.NewModuleAddress(module).String()
+
+			sublogger.Debug().
+				Str("module", module).
+				Msg("Started querying module account balance")
+			queryStart := time.Now()
+
+			bankClient := banktypes.NewQueryClient(grpcConn)
+			response, err := bankClient.AllBalances(
+				context.Background(),
+				&banktypes.QueryAllBalancesRequest{Address: moduleAddress},
+			)
+			if err != nil {
+				sublogger.Error().
+					Str("module", module).
+					Err(err).
+					Msg("Could not get module account balance")
+				return
+			}
+
+			sublogger.Debug().
+				Str("module", module).
+				Float64("request-time", time.Since(queryStart).Seconds()).
+				Msg("Finished querying module account balance")
+
+			for _, coin := range response.Balances {
+				if value, err := strconv.ParseFloat(coin.Amount.String(), 64); err != nil {
+					sublogger.Error().
+						Str("module", module).
+						Err(err).
+						Msg("Could not parse module account balance")
+				} else {
+					denom, value := getCoinValue(coin.Denom, value)
+					generalModuleAccountBalanceGauge.With(prometheus.Labels{
+						"module":  module,
+						"address": moduleAddress,
+						"denom":   denom,
+					}).Set(value)
+				}
+			}
+		}(module)
+	}
+
 	wg.Wait()
 
 	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
@@ -245,3 +737,116 @@ func GeneralHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Clien
 		Float64("request-time", time.Since(requestStart).Seconds()).
 		Msg("Request processed")
 }
+
+// getNakamotoCoefficient returns the minimal amount of validators
+// whose combined voting power is more than the threshold share of the total.
+func getNakamotoCoefficient(votingPowers []float64, threshold float64) int {
+	sorted := make([]float64, len(votingPowers))
+	copy(sorted, votingPowers)
+	sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+
+	var total float64
+	for _, votingPower := range sorted {
+		total += votingPower
+	}
+
+	var cumulative float64
+	for index, votingPower := range sorted {
+		cumulative += votingPower
+		if cumulative > total*threshold {
+			return index + 1
+		}
+	}
+
+	return len(sorted)
+}
+
+// getGiniCoefficient returns 0 if all validators have equal voting power
+// and approaches 1 as it concentrates on a single validator.
+func getGiniCoefficient(votingPowers []float64) float64 {
+	sorted := make([]float64, len(votingPowers))
+	copy(sorted, votingPowers)
+	sort.Float64s(sorted)
+
+	var total, weightedTotal float64
+	for index, votingPower := range sorted {
+		total += votingPower
+		weightedTotal += float64(index+1) * votingPower
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	count := float64(len(sorted))
+	return 2*weightedTotal/(count*total) - (count+1)/count
+}
+
+// getModuleAccounts returns the well-known module accounts with the ones from --module-accounts.
+func getModuleAccounts() []string {
+	modules := []string{
+		authtypes.FeeCollectorName,
+		distributiontypes.ModuleName,
+		stakingtypes.BondedPoolName,
+		stakingtypes.NotBondedPoolName,
+		govtypes.ModuleName,
+	}
+
+	for _, module := range splitList(ModuleAccounts) {
+		known := false
+		for _, knownModule := range modules {
+			if module == knownModule {
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			modules = append(modules, module)
+		}
+	}
+
+	return modules
+}
+
+// getNonCirculatingBalances exports the bond denom balances of --non-circulating-addresses
+// and returns their sum. All of them are required, otherwise the circulating supply would be wrong.
+func getNonCirculatingBalances(
+	grpcConn *grpc.ClientConn,
+	sublogger zerolog.Logger,
+	nonCirculatingBalanceGauge *prometheus.GaugeVec,
+) (float64, error) {
+	var sum float64
+
+	bankClient := banktypes.NewQueryClient(grpcConn)
+
+	for _, address := range splitList(NonCirculatingAddresses) {
+		response, err := bankClient.Balance(
+			context.Background(),
+			&banktypes.QueryBalanceRequest{Address: address, Denom: BondDenom},
+		)
+		if err != nil {
+			return 0, fmt.Errorf("could not get balance of %s: %s", address, err)
+		}
+
+		value, err := strconv.ParseFloat(response.Balance.Amount.String(), 64)
+		if err != nil {
+			return 0, fmt.Errorf("could not parse balance of %s: %s", address, err)
+		}
+
+		sublogger.Debug().
+			Str("address", address).
+			Float64("balance", value).
+			Msg("Got non-circulating balance")
+
+		sum += value
+
+		denom, value := getCoinValue(BondDenom, value)
+		nonCirculatingBalanceGauge.With(prometheus.Labels{
+			"address": address,
+			"denom":   denom,
+		}).Set(value)
+	}
+
+	return sum, nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGetNakamotoCoefficient(t *testing.T) {
+	tests := []struct {
+		name         string
+		votingPowers []float64
+		threshold    float64
+		expected     int
+	}{
+		{"no validators", nil, 1.0 / 3, 0},
+		{"single validator", []float64{100}, 2.0 / 3, 1},
+		{"equal one third", []float64{10, 10, 10}, 1.0 / 3, 2},
+		{"equal two thirds", []float64{10, 10, 10}, 2.0 / 3, 3},
+		{"largest halts alone", []float64{20, 50, 30}, 1.0 / 3, 1},
+		{"unsorted two thirds", []float64{20, 50, 30}, 2.0 / 3, 2},
+		{"exactly threshold is not enough", []float64{50, 25, 25}, 1.0 / 2, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := getNakamotoCoefficient(test.votingPowers, test.threshold); actual != test.expected {
+				t.Errorf("expected %d, got %d", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGetGiniCoefficient(t *testing.T) {
+	tests := []struct {
+		name         string
+		votingPowers []float64
+		expected     float64
+	}{
+		{"no voting power", []float64{0, 0}, 0},
+		{"equal", []float64{10, 10, 10, 10}, 0},
+		{"single holder", []float64{0, 0, 0, 1}, 0.75},
+		{"unsorted", []float64{3, 1, 2}, 2.0 / 9},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := getGiniCoefficient(test.votingPowers); math.Abs(actual-test.expected) > 1e-9 {
+				t.Errorf("expected %f, got %f", test.expected, actual)
+			}
+		})
+	}
+}
@@ -1,4 +1,4 @@
-module main
+module github.com/solarlabsteam/cosmos-exporter
 
 go 1.16
 
@@ -8,6 +8,8 @@ replace google.golang.org/grpc => google.golang.org/grpc v1.33.2
 
 require (
 	github.com/cosmos/cosmos-sdk v0.42.4
+	github.com/gogo/protobuf v1.3.3
+	github.com/golang/protobuf v1.4.3
 	github.com/google/uuid v1.2.0
 	github.com/prometheus/client_golang v1.8.0
 	github.com/rs/zerolog v1.20.0
@@ -16,4 +18,5 @@ require (
 	github.com/spf13/viper v1.7.1
 	github.com/tendermint/tendermint v0.34.9
 	google.golang.org/grpc v1.35.0
+	google.golang.org/protobuf v1.25.0
 )
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// GrpcMetric is a metric taken from the response of an arbitrary gRPC query,
+// configured in the config file as:
+//
+// [[grpc-metrics]]
+// name = "oracle_exchange_rate"
+// help = "Exchange rates from the oracle module"
+// method = "terra.oracle.v1beta1.Query/ExchangeRates"
+// request = '{}'
+// items = "exchange_rates"
+// value = "amount"
+// [grpc-metrics.labels]
+// denom = "denom"
+//
+// The response is converted to JSON with the proto field names and enums as numbers
+// before the paths are applied.
+type GrpcMetric struct {
+	JSONMetric `mapstructure:",squash"`
+	Method     string `mapstructure:"method"`
+	Request    string `mapstructure:"request"`
+}
+
+func (m GrpcMetric) Validate() error {
+	if err := m.JSONMetric.Validate(); err != nil {
+		return err
+	}
+
+	if _, _, err := parseGrpcMethod(m.Method); err != nil {
+		return fmt.Errorf("invalid method for metric %s: %s", m.Name, err)
+	}
+
+	if m.Request != "" && !json.Valid([]byte(m.Request)) {
+		return fmt.Errorf("request is not a valid JSON for metric %s", m.Name)
+	}
+
+	return nil
+}
+
+// parseGrpcMethod splits the method name like "cosmos.bank.v1beta1.Query/Balance"
+// into the service and method names.
+func parseGrpcMethod(method string) (protoreflect.FullName, protoreflect.Name, error) {
+	parts := strings.Split(strings.TrimPrefix(method, "/"), "/")
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("expected package.Service/Method, got %s", method)
+	}
+
+	service, name := protoreflect.FullName(parts[0]), protoreflect.Name(parts[1])
+	if !service.IsValid() || !name.IsValid() {
+		return "", "", fmt.Errorf("expected package.Service/Method, got %s", method)
+	}
+
+	return service, name, nil
+}
+
+type grpcMethod struct {
+	Descriptor protoreflect.MethodDescriptor
+	// Types is used to resolve the google.protobuf.Any messages in the response.
+	Types *protoregistry.Types
+}
+
+// GrpcDescriptorResolver finds the method descriptors either in the descriptor set provided
+// with --grpc-descriptor-set or, if it's not set, via the gRPC server reflection.
+// Resolved methods are cached, failed ones are retried on the next scrape.
+type GrpcDescriptorResolver struct {
+	grpcConn *grpc.ClientConn
+	files    *protoregistry.Files
+
+	mutex   sync.Mutex
+	methods map[string]grpcMethod
+}
+
+func NewGrpcDescriptorResolver(grpcConn *grpc.ClientConn, descriptorSetPath string) (*GrpcDescriptorResolver, error) {
+	resolver := &GrpcDescriptorResolver{
+		grpcConn: grpcConn,
+		methods:  make(map[string]grpcMethod),
+	}
+
+	if descriptorSetPath == "" {
+		return resolver, nil
+	}
+
+	content, err := ioutil.ReadFile(descriptorSetPath)
+	if err != nil {
+		return nil, err
+	}
+
+	fileSet := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(content, fileSet); err != nil {
+		return nil, err
+	}
+
+	if resolver.files, err = newGrpcFiles(fileSet); err != nil {
+		return nil, err
+	}
+
+	return resolver, nil
+}
+
+func (r *GrpcDescriptorResolver) getMethod(method string) (grpcMethod, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if resolved, ok := r.methods[method]; ok {
+		return resolved, nil
+	}
+
+	serviceName, methodName, err := parseGrpcMethod(method)
+	if err != nil {
+		return grpcMethod{}, err
+	}
+
+	files := r.files
+	if files == nil {
+		if files, err = r.getReflectionFiles(serviceName); err != nil {
+			return grpcMethod{}, err
+		}
+	}
+
+	descriptor, err := files.FindDescriptorByName(serviceName)
+	if err != nil {
+		return grpcMethod{}, err
+	}
+
+	service, ok := descriptor.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return grpcMethod{}, fmt.Errorf("%s is not a service", serviceName)
+	}
+
+	methodDescriptor := service.Methods().ByName(methodName)
+	if methodDescriptor == nil {
+		return grpcMethod{}, fmt.Errorf("method %s not found in service %s", methodName, serviceName)
+	}
+
+	if methodDescriptor.IsStreamingClient() || methodDescriptor.IsStreamingServer() {
+		return grpcMethod{}, fmt.Errorf("streaming method %s is not supported", method)
+	}
+
+	resolved := grpcMethod{
+		Descriptor: methodDescriptor,
+		Types:      newGrpcTypes(files),
+	}
+
+	r.methods[method] = resolved
+	return resolved, nil
+}
+
+// getReflectionFiles fetches the file containing the service and all its dependencies.
+// Dependencies the server doesn't know about (like gogoproto options) are skipped,
+// as they are usually not needed to encode the messages.
+func (r *GrpcDescriptorResolver) getReflectionFiles(service protoreflect.FullName) (*protoregistry.Files, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := reflectionpb.NewServerReflectionClient(r.grpcConn)
+	stream, err := client.ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fileSet := &descriptorpb.FileDescriptorSet{}
+	requested := make(map[string]bool)
+	received := make(map[string]bool)
+
+	request := &reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{
+			FileContainingSymbol: string(service),
+		},
+	}
+
+	for request != nil {
+		if err := stream.Send(request); err != nil {
+			return nil, err
+		}
+
+		response, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+
+		if errorResponse := response.GetErrorResponse(); errorResponse != nil {
+			if len(fileSet.File) == 0 {
+				return nil, fmt.Errorf("could not get service descriptor: %s", errorResponse.ErrorMessage)
+			}
+
+			log.Debug().
+				Str("file", request.GetFileByFilename()).
+				Str("error", errorResponse.ErrorMessage).
+				Msg("Could not get file descriptor via reflection, skipping")
+		}
+
+		for _, rawFile := range response.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			file := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(rawFile, file); err != nil {
+				return nil, err
+			}
+
+			if received[file.GetName()] {
+				continue
+			}
+
+			received[file.GetName()] = true
+			fileSet.File = append(fileSet.File, file)
+		}
+
+		request = nil
+		for _, file := range fileSet.File {
+			for _, dependency := range file.Dependency {
+				if received[dependency] || requested[dependency] {
+					continue
+				}
+
+				requested[dependency] = true
+				request = &reflectionpb.ServerReflectionRequest{
+					MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{
+						FileByFilename: dependency,
+					},
+				}
+				break
+			}
+
+			if request != nil {
+				break
+			}
+		}
+	}
+
+	return newGrpcFiles(fileSet)
+}
+
+func newGrpcFiles(fileSet *descriptorpb.FileDescriptorSet) (*protoregistry.Files, error) {
+	return protodesc.FileOptions{AllowUnresolvable: true}.NewFiles(fileSet)
+}
+
+func newGrpcTypes(files *protoregistry.Files) *protoregistry.Types {
+	types := &protoregistry.Types{}
+
+	var registerMessages func(messages protoreflect.MessageDescriptors)
+	registerMessages = func(messages protoreflect.MessageDescriptors) {
+		for i := 0; i < messages.Len(); i++ {
+			// errors are only returned on conflicts, which can be ignored here
+			_ = types.RegisterMessage(dynamicpb.NewMessageType(messages.Get(i)))
+			registerMessages(messages.Get(i).Messages())
+		}
+	}
+
+	files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		registerMessages(file.Messages())
+		return true
+	})
+
+	return types
+}
+
+func queryGrpcMethod(grpcConn *grpc.ClientConn, resolver *GrpcDescriptorResolver, metric GrpcMetric) (interface{}, error) {
+	method, err := resolver.getMethod(metric.Method)
+	if err != nil {
+		return nil, err
+	}
+
+	request := dynamicpb.NewMessage(method.Descriptor.Input())
+	if metric.Request != "" {
+		if err := protojson.Unmarshal([]byte(metric.Request), request); err != nil {
+			return nil, err
+		}
+	}
+
+	response := dynamicpb.NewMessage(method.Descriptor.Output())
+	if err := grpcConn.Invoke(
+		context.Background(),
+		"/"+strings.TrimPrefix(metric.Method, "/"),
+		request,
+		response,
+	); err != nil {
+		return nil, err
+	}
+
+	// zero values are emitted too, otherwise a zero value would look like a missing field
+	encoded, err := protojson.MarshalOptions{
+		UseProtoNames:   true,
+		UseEnumNumbers:  true,
+		EmitUnpopulated: true,
+		Resolver:        method.Types,
+	}.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func GrpcMetricsHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.ClientConn, resolver *GrpcDescriptorResolver) {
+	requestStart := time.Now()
+
+	sublogger := log.With().
+		Str("request-id", uuid.New().String()).
+		Logger()
+
+	registry := prometheus.NewRegistry()
+
+	var wg sync.WaitGroup
+
+	for _, metric := range GrpcMetrics {
+		gauge := prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_grpc_" + metric.Name,
+				Help:        metric.Help,
+				ConstLabels: ConstLabels,
+			},
+			metric.LabelNames(),
+		)
+
+		if err := registry.Register(gauge); err != nil {
+			sublogger.Error().
+				Str("metric", metric.Name).
+				Err(err).
+				Msg("Could not register gRPC metric")
+			continue
+		}
+
+		wg.Add(1)
+		go func(metric GrpcMetric, gauge *prometheus.GaugeVec) {
+			defer wg.Done()
+
+			metricLogger := sublogger.With().
+				Str("metric", metric.Name).
+				Str("method", metric.Method).
+				Logger()
+
+			metricLogger.Debug().Msg("Started querying gRPC method")
+			queryStart := time.Now()
+
+			data, err := queryGrpcMethod(grpcConn, resolver, metric)
+			if err != nil {
+				metricLogger.Error().Err(err).Msg("Could not query gRPC method")
+				return
+			}
+
+			metricLogger.Debug().
+				Float64("request-time", time.Since(queryStart).Seconds()).
+				Msg("Finished querying gRPC method")
+
+			metric.Set(gauge, data, prometheus.Labels{}, metricLogger)
+		}(metric, gauge)
+	}
+
+	wg.Wait()
+
+	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	h.ServeHTTP(w, r)
+	sublogger.Info().
+		Str("method", "GET").
+		Str("endpoint", "/metrics/grpc").
+		Float64("request-time", time.Since(requestStart).Seconds()).
+		Msg("Request processed")
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+)
+
+// jsonPathKey is used in the labels paths to get the key of the object item
+// when iterating over an object instead of an array.
+const jsonPathKey = "$key"
+
+// jsonItem is a single element of the JSON array or object the metrics are taken from.
+type jsonItem struct {
+	Key   string
+	Value interface{}
+}
+
+// getJSONPath walks the decoded JSON by the dot-separated path like "balances.0.amount",
+// numeric parts are used as array indexes. Empty path returns the data itself.
+func getJSONPath(data interface{}, path string) (interface{}, error) {
+	if path == "" {
+		return data, nil
+	}
+
+	current := data
+
+	for _, part := range strings.Split(path, ".") {
+		switch value := current.(type) {
+		case map[string]interface{}:
+			next, ok := value[part]
+			if !ok {
+				return nil, fmt.Errorf("key %s not found in path %s", part, path)
+			}
+
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("expected array index, got %s in path %s", part, path)
+			}
+
+			if index < 0 || index >= len(value) {
+				return nil, fmt.Errorf("index %d out of range in path %s", index, path)
+			}
+
+			current = value[index]
+		default:
+			return nil, fmt.Errorf("cannot get %s of a scalar value in path %s", part, path)
+		}
+	}
+
+	return current, nil
+}
+
+// getJSONItems returns the elements of the array or object at the path,
+// object elements are sorted by key to keep the output stable.
+func getJSONItems(data interface{}, path string) ([]jsonItem, error) {
+	value, err := getJSONPath(data, path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch items := value.(type) {
+	case []interface{}:
+		result := make([]jsonItem, len(items))
+		for index, item := range items {
+			result[index] = jsonItem{Key: strconv.Itoa(index), Value: item}
+		}
+
+		return result, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(items))
+		for key := range items {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		result := make([]jsonItem, len(keys))
+		for index, key := range keys {
+			result[index] = jsonItem{Key: key, Value: items[key]}
+		}
+
+		return result, nil
+	default:
+		return nil, fmt.Errorf("expected array or object at path %s", path)
+	}
+}
+
+// jsonValueToFloat accepts both numbers and strings, as big numbers are usually serialized as strings.
+func jsonValueToFloat(value interface{}) (float64, error) {
+	switch typed := value.(type) {
+	case float64:
+		return typed, nil
+	case string:
+		return strconv.ParseFloat(typed, 64)
+	case bool:
+		if typed {
+			return 1, nil
+		}
+
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("cannot convert %v to number", value)
+	}
+}
+
+func jsonValueToString(value interface{}) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		encoded, err := json.Marshal(typed)
+		if err != nil {
+			return fmt.Sprintf("%v", typed)
+		}
+
+		return string(encoded)
+	}
+}
+
+// getJSONItemLabel returns the label value from the item, supporting jsonPathKey.
+func getJSONItemLabel(item jsonItem, path string) (string, error) {
+	if path == jsonPathKey {
+		return item.Key, nil
+	}
+
+	value, err := getJSONPath(item.Value, path)
+	if err != nil {
+		return "", err
+	}
+
+	return jsonValueToString(value), nil
+}
+
+// JSONMetric describes how to build a gauge from a JSON document, it's shared by all
+// the user-defined metrics. If Items is set, it should point to an array or an object,
+// and each of its elements produces a separate series, with Value and Labels paths
+// relative to the element.
+type JSONMetric struct {
+	Name   string            `mapstructure:"name"`
+	Help   string            `mapstructure:"help"`
+	Items  string            `mapstructure:"items"`
+	Value  string            `mapstructure:"value"`
+	Labels map[string]string `mapstructure:"labels"`
+}
+
+func (m JSONMetric) Validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("name is not set")
+	}
+
+	return nil
+}
+
+// LabelNames returns the extra labels set by the caller followed by the configured labels.
+func (m JSONMetric) LabelNames(extraLabels ...string) []string {
+	labels := make([]string, 0, len(m.Labels))
+	for label := range m.Labels {
+		labels = append(labels, label)
+	}
+
+	sort.Strings(labels)
+	return append(extraLabels, labels...)
+}
+
+// Set takes the values and labels from the data and sets them to the gauge,
+// baseLabels should contain the values for the extra labels passed to LabelNames.
+func (m JSONMetric) Set(
+	gauge *prometheus.GaugeVec,
+	data interface{},
+	baseLabels prometheus.Labels,
+	sublogger zerolog.Logger,
+) {
+	items := []jsonItem{{Value: data}}
+	if m.Items != "" {
+		var err error
+		if items, err = getJSONItems(data, m.Items); err != nil {
+			sublogger.Error().Err(err).Msg("Could not get items from response")
+			return
+		}
+	}
+
+	for _, item := range items {
+		rawValue, err := getJSONPath(item.Value, m.Value)
+		if err != nil {
+			sublogger.Error().Err(err).Msg("Could not get value from response")
+			continue
+		}
+
+		value, err := jsonValueToFloat(rawValue)
+		if err != nil {
+			sublogger.Error().Err(err).Msg("Could not parse value from response")
+			continue
+		}
+
+		labels := prometheus.Labels{}
+		for label, labelValue := range baseLabels {
+			labels[label] = labelValue
+		}
+
+		labelsFound := true
+		for label, path := range m.Labels {
+			if labels[label], err = getJSONItemLabel(item, path); err != nil {
+				sublogger.Error().
+					Str("label", label).
+					Err(err).
+					Msg("Could not get label from response")
+				labelsFound = false
+				break
+			}
+		}
+
+		if labelsFound {
+			gauge.With(labels).Set(value)
+		}
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"math"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
@@ -28,6 +30,35 @@ var (
 	JsonOutput    bool
 	Limit         uint64
 
+	BlockTimeWindow uint64
+	WalletTxsWindow uint64
+
+	ExpectedPeers string
+
+	TransfersAddresses string
+	TransfersEvents    string
+
+	DelegationsMode string
+	DelegationsTopN uint64
+
+	StakingAPR float64
+	APRSource  StakingAPRSource
+
+	ProposalsWindow       uint64
+	ProposalsPollInterval time.Duration
+
+	WasmMetrics []WasmMetric
+
+	GrpcMetrics       []GrpcMetric
+	GrpcDescriptorSet string
+
+	PriceSourceType   string
+	PriceURL          string
+	PriceFile         string
+	PriceCurrency     string
+	PricePollInterval time.Duration
+	PriceStaleAfter   time.Duration
+
 	Prefix                    string
 	AccountPrefix             string
 	AccountPubkeyPrefix       string
@@ -40,6 +71,9 @@ var (
 	ConstLabels      map[string]string
 	DenomCoefficient float64
 	DenomExponent    uint64
+
+	ModuleAccounts          string
+	NonCirculatingAddresses string
 )
 
 var log = zerolog.New(zerolog.ConsoleWriter{Out: os.Stdout}).With().Timestamp().Logger()
@@ -128,6 +162,12 @@ func Execute(cmd *cobra.Command, args []string) {
 
 	zerolog.SetGlobalLevel(logLevel)
 
+	if DelegationsMode != DelegationsModeFull &&
+		DelegationsMode != DelegationsModeTop &&
+		DelegationsMode != DelegationsModeAggregate {
+		log.Fatal().Str("mode", DelegationsMode).Msg("Unsupported delegations mode")
+	}
+
 	log.Info().
 		Str("--bech-account-prefix", AccountPrefix).
 		Str("--bech-account-pubkey-prefix", AccountPubkeyPrefix).
@@ -143,6 +183,68 @@ func Execute(cmd *cobra.Command, args []string) {
 		Str("--log-level", LogLevel).
 		Msg("Started with following parameters")
 
+	// wasm metrics can't be expressed with flags, so they are only taken from the config file
+	if err := viper.UnmarshalKey("wasm-metrics", &WasmMetrics); err != nil {
+		log.Fatal().Err(err).Msg("Could not parse wasm metrics config")
+	}
+
+	for _, metric := range WasmMetrics {
+		if err := metric.Validate(); err != nil {
+			log.Fatal().Err(err).Msg("Invalid wasm metric config")
+		}
+	}
+
+	if err := viper.UnmarshalKey("grpc-metrics", &GrpcMetrics); err != nil {
+		log.Fatal().Err(err).Msg("Could not parse gRPC metrics config")
+	}
+
+	for _, metric := range GrpcMetrics {
+		if err := metric.Validate(); err != nil {
+			log.Fatal().Err(err).Msg("Invalid gRPC metric config")
+		}
+	}
+
+	// the paths can't be expressed with flags, so they are only taken from the config file,
+	// as a list and not a map, as viper lowercases the map keys and IBC denoms have uppercase hashes
+	var pricePathsList []PricePath
+	if err := viper.UnmarshalKey("price-paths", &pricePathsList); err != nil {
+		log.Fatal().Err(err).Msg("Could not parse price paths config")
+	}
+
+	pricePaths := make(map[string]string, len(pricePathsList))
+	priceDenoms := make([]string, 0, len(pricePathsList))
+	for _, pricePath := range pricePathsList {
+		pricePaths[pricePath.Denom] = pricePath.Path
+		priceDenoms = append(priceDenoms, pricePath.Denom)
+	}
+
+	var priceSource PriceSource
+
+	switch PriceSourceType {
+	case "":
+	case PriceSourceHTTP:
+		priceSource = HTTPPriceSource{
+			URL:    PriceURL,
+			Paths:  pricePaths,
+			Client: &http.Client{Timeout: 10 * time.Second},
+		}
+	case PriceSourceFile:
+		priceSource = FilePriceSource{Path: PriceFile, Paths: pricePaths}
+	default:
+		log.Fatal().Str("source", PriceSourceType).Msg("Unsupported price source")
+	}
+
+	if TransfersEvents != TransfersEventsCoin && TransfersEvents != TransfersEventsTransfer {
+		log.Fatal().Str("events", TransfersEvents).Msg("Unsupported transfers events type")
+	}
+
+	// some chains replaced the mint module, so the APR can't be calculated for them
+	if StakingAPR != 0 {
+		APRSource = StaticStakingAPRSource{APR: StakingAPR}
+	} else {
+		APRSource = MintStakingAPRSource{}
+	}
+
 	config := sdk.GetConfig()
 	config.SetBech32PrefixForAccount(AccountPrefix, AccountPubkeyPrefix)
 	config.SetBech32PrefixForValidator(ValidatorPrefix, ValidatorPubkeyPrefix)
@@ -157,11 +259,25 @@ func Execute(cmd *cobra.Command, args []string) {
 		log.Fatal().Err(err).Msg("Could not connect to gRPC node")
 	}
 
-	setChainID()
+	tendermintClient, err := tmrpc.New(TendermintRPC, "/websocket")
+	if err != nil {
+		log.Fatal().Err(err).Msg("Could not create Tendermint client")
+	}
+
+	setChainID(tendermintClient)
 	setDenom(grpcConn)
+	setBondDenom(grpcConn)
 
 	http.HandleFunc("/metrics/wallet", func(w http.ResponseWriter, r *http.Request) {
-		WalletHandler(w, r, grpcConn)
+		WalletHandler(w, r, grpcConn, tendermintClient)
+	})
+
+	http.HandleFunc("/metrics/authz", func(w http.ResponseWriter, r *http.Request) {
+		AuthzHandler(w, r, grpcConn)
+	})
+
+	http.HandleFunc("/metrics/feegrant", func(w http.ResponseWriter, r *http.Request) {
+		FeegrantHandler(w, r, grpcConn)
 	})
 
 	http.HandleFunc("/metrics/validator", func(w http.ResponseWriter, r *http.Request) {
@@ -180,6 +296,63 @@ func Execute(cmd *cobra.Command, args []string) {
 		GeneralHandler(w, r, grpcConn)
 	})
 
+	http.HandleFunc("/metrics/consensus", func(w http.ResponseWriter, r *http.Request) {
+		ConsensusHandler(w, r, grpcConn, tendermintClient)
+	})
+
+	http.HandleFunc("/metrics/blocks", func(w http.ResponseWriter, r *http.Request) {
+		BlocksHandler(w, r, grpcConn, tendermintClient)
+	})
+
+	http.HandleFunc("/metrics/peers", func(w http.ResponseWriter, r *http.Request) {
+		PeersHandler(w, r, tendermintClient)
+	})
+
+	evidenceCounter := NewEvidenceCounter()
+	http.HandleFunc("/metrics/evidence", func(w http.ResponseWriter, r *http.Request) {
+		EvidenceHandler(w, r, grpcConn, evidenceCounter)
+	})
+
+	http.HandleFunc("/metrics/wasm", func(w http.ResponseWriter, r *http.Request) {
+		WasmHandler(w, r, grpcConn)
+	})
+
+	grpcDescriptorResolver, err := NewGrpcDescriptorResolver(grpcConn, GrpcDescriptorSet)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Could not load gRPC descriptor set")
+	}
+
+	http.HandleFunc("/metrics/grpc", func(w http.ResponseWriter, r *http.Request) {
+		GrpcMetricsHandler(w, r, grpcConn, grpcDescriptorResolver)
+	})
+
+	if TransfersAddresses != "" {
+		transfersWatcher := NewTransfersWatcher(tendermintClient, splitList(TransfersAddresses), TransfersEvents)
+		go transfersWatcher.Start()
+
+		http.HandleFunc("/metrics/transfers", func(w http.ResponseWriter, r *http.Request) {
+			TransfersHandler(w, r, transfersWatcher)
+		})
+	}
+
+	if priceSource != nil {
+		pricesTracker := NewPricesTracker(priceSource, priceDenoms, PriceStaleAfter)
+		go pricesTracker.Start(PricePollInterval)
+
+		http.HandleFunc("/metrics/prices", func(w http.ResponseWriter, r *http.Request) {
+			PricesHandler(w, r, pricesTracker)
+		})
+	}
+
+	if ProposalsWindow != 0 {
+		proposalsTracker := NewProposalsTracker(tendermintClient, int64(ProposalsWindow))
+		go proposalsTracker.Start(ProposalsPollInterval)
+
+		http.HandleFunc("/metrics/proposals", func(w http.ResponseWriter, r *http.Request) {
+			ProposalsHandler(w, r, grpcConn, proposalsTracker)
+		})
+	}
+
 	log.Info().Str("address", ListenAddress).Msg("Listening")
 	err = http.ListenAndServe(ListenAddress, nil)
 	if err != nil {
@@ -187,12 +360,7 @@ func Execute(cmd *cobra.Command, args []string) {
 	}
 }
 
-func setChainID() {
-	client, err := tmrpc.New(TendermintRPC, "/websocket")
-	if err != nil {
-		log.Fatal().Err(err).Msg("Could not create Tendermint client")
-	}
-
+func setChainID(client *tmrpc.HTTP) {
 	status, err := client.Status(context.Background())
 	if err != nil {
 		log.Fatal().Err(err).Msg("Could not query Tendermint status")
@@ -291,6 +459,25 @@ func main() {
 	rootCmd.PersistentFlags().Uint64Var(&Limit, "limit", 1000, "Pagination limit for gRPC requests")
 	rootCmd.PersistentFlags().StringVar(&TendermintRPC, "tendermint-rpc", "http://localhost:26657", "Tendermint RPC address")
 	rootCmd.PersistentFlags().BoolVar(&JsonOutput, "json", false, "Output logs as JSON")
+	rootCmd.PersistentFlags().Uint64Var(&ProposalsWindow, "proposals-window", 0, "Amount of latest blocks to track the proposers of, 0 to disable tracking")
+	rootCmd.PersistentFlags().DurationVar(&ProposalsPollInterval, "proposals-poll-interval", 5*time.Second, "Interval to poll Tendermint for new blocks to track the proposers of")
+	rootCmd.PersistentFlags().StringVar(&DelegationsMode, "delegations-mode", DelegationsModeFull, "How to export per-delegator validator metrics: full, top or aggregate")
+	rootCmd.PersistentFlags().Uint64Var(&DelegationsTopN, "delegations-top-n", 10, "Amount of largest delegators to export per-delegator validator metrics for in top mode")
+	rootCmd.PersistentFlags().Float64Var(&StakingAPR, "staking-apr", 0, "Staking APR to use instead of calculating it from the mint module, for chains that replaced it")
+	rootCmd.PersistentFlags().Uint64Var(&BlockTimeWindow, "block-time-window", 100, "Amount of latest blocks to calculate the average block time from")
+	rootCmd.PersistentFlags().Uint64Var(&WalletTxsWindow, "wallet-txs-window", 1000, "Amount of latest blocks to count the wallet transactions in")
+	rootCmd.PersistentFlags().StringVar(&ModuleAccounts, "module-accounts", "", "Comma-separated list of module accounts names to export the balances of, in addition to the well-known ones")
+	rootCmd.PersistentFlags().StringVar(&NonCirculatingAddresses, "non-circulating-addresses", "", "Comma-separated list of addresses (lockups, vesting, module accounts) to subtract from the total supply to get the circulating one")
+	rootCmd.PersistentFlags().StringVar(&ExpectedPeers, "expected-peers", "", "Comma-separated list of peers the node should be connected to, in the id@host:port format")
+	rootCmd.PersistentFlags().StringVar(&TransfersAddresses, "transfers-addresses", "", "Comma-separated list of addresses to count the sent and received tokens of via the Tendermint websocket")
+	rootCmd.PersistentFlags().StringVar(&TransfersEvents, "transfers-events", TransfersEventsCoin, "Events to count the transfers by: coin (coin_received/coin_spent, cosmos-sdk >= 0.44) or transfer")
+	rootCmd.PersistentFlags().StringVar(&PriceSourceType, "price-source", "", "Source to take the denoms prices from, http or file. Prices are disabled if not set")
+	rootCmd.PersistentFlags().StringVar(&PriceURL, "price-url", "", "URL returning JSON with prices, used with the http price source")
+	rootCmd.PersistentFlags().StringVar(&PriceFile, "price-file", "", "Path to the JSON file with prices, used with the file price source")
+	rootCmd.PersistentFlags().StringVar(&PriceCurrency, "price-currency", "usd", "Currency the prices are in, used as the label value")
+	rootCmd.PersistentFlags().DurationVar(&PricePollInterval, "price-poll-interval", time.Minute, "How often to update the prices")
+	rootCmd.PersistentFlags().DurationVar(&PriceStaleAfter, "price-stale-after", 10*time.Minute, "Time after the last successful update when the price is considered stale")
+	rootCmd.PersistentFlags().StringVar(&GrpcDescriptorSet, "grpc-descriptor-set", "", "Path to the protobuf descriptor set to resolve gRPC metrics methods with instead of the server reflection")
 
 	// some networks, like Iris, have the different prefixes for address, validator and consensus node
 	rootCmd.PersistentFlags().StringVar(&Prefix, "bech-prefix", "persistence", "Bech32 global prefix")
@@ -305,3 +492,16 @@ func main() {
 		log.Fatal().Err(err).Msg("Could not start application")
 	}
 }
+
+// splitList parses the comma-separated flag value, skipping the empty items.
+func splitList(list string) []string {
+	var items []string
+
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	tmrpc "github.com/tendermint/tendermint/rpc/client/http"
+)
+
+// parseExpectedPeers takes the node IDs from the list in the same format as Tendermint's
+// persistent_peers, "id@host:port,id@host:port", the addresses are optional.
+func parseExpectedPeers(peers string) []string {
+	var nodeIDs []string
+
+	for _, peer := range splitList(peers) {
+		nodeIDs = append(nodeIDs, strings.SplitN(peer, "@", 2)[0])
+	}
+
+	return nodeIDs
+}
+
+func PeersHandler(w http.ResponseWriter, r *http.Request, tendermintClient *tmrpc.HTTP) {
+	requestStart := time.Now()
+
+	sublogger := log.With().
+		Str("request-id", uuid.New().String()).
+		Logger()
+
+	peersCountGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_peers_count",
+			Help:        "Amount of peers the node is connected to",
+			ConstLabels: ConstLabels,
+		},
+	)
+
+	peerInfoGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_peer_info",
+			Help:        "Peer the node is connected to, always 1",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"node_id", "moniker", "remote_ip", "direction", "version"},
+	)
+
+	peerSendRateGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_peer_send_rate",
+			Help:        "Current send rate to the peer, in bytes per second",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"node_id"},
+	)
+
+	peerRecvRateGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_peer_recv_rate",
+			Help:        "Current receive rate from the peer, in bytes per second",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"node_id"},
+	)
+
+	peerConnectionDurationGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_peer_connection_duration_seconds",
+			Help:        "How long the node is connected to the peer, in seconds",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"node_id"},
+	)
+
+	peerExpectedConnectedGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_peer_expected_connected",
+			Help:        "1 if the node is connected to the peer from --expected-peers, 0 otherwise",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"node_id"},
+	)
+
+	peersExpectedMissingGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_peers_expected_missing",
+			Help:        "Amount of peers from --expected-peers the node is not connected to",
+			ConstLabels: ConstLabels,
+		},
+	)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(peersCountGauge)
+	registry.MustRegister(peerInfoGauge)
+	registry.MustRegister(peerSendRateGauge)
+	registry.MustRegister(peerRecvRateGauge)
+	registry.MustRegister(peerConnectionDurationGauge)
+	registry.MustRegister(peerExpectedConnectedGauge)
+	registry.MustRegister(peersExpectedMissingGauge)
+
+	sublogger.Debug().Msg("Started querying net info")
+	queryStart := time.Now()
+
+	netInfo, err := tendermintClient.NetInfo(context.Background())
+	if err != nil {
+		// without the connected peers list the expected peers would all look missing
+		sublogger.Error().Err(err).Msg("Could not get net info")
+		h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+		h.ServeHTTP(w, r)
+		return
+	}
+
+	sublogger.Debug().
+		Float64("request-time", time.Since(queryStart).Seconds()).
+		Msg("Finished querying net info")
+
+	peersCountGauge.Set(float64(netInfo.NPeers))
+
+	connectedPeers := make(map[string]bool, len(netInfo.Peers))
+
+	for _, peer := range netInfo.Peers {
+		nodeID := string(peer.NodeInfo.DefaultNodeID)
+		connectedPeers[nodeID] = true
+
+		direction := "inbound"
+		if peer.IsOutbound {
+			direction = "outbound"
+		}
+
+		peerInfoGauge.With(prometheus.Labels{
+			"node_id":   nodeID,
+			"moniker":   peer.NodeInfo.Moniker,
+			"remote_ip": peer.RemoteIP,
+			"direction": direction,
+			"version":   peer.NodeInfo.Version,
+		}).Set(1)
+
+		labels := prometheus.Labels{"node_id": nodeID}
+		peerSendRateGauge.With(labels).Set(float64(peer.ConnectionStatus.SendMonitor.CurRate))
+		peerRecvRateGauge.With(labels).Set(float64(peer.ConnectionStatus.RecvMonitor.CurRate))
+		peerConnectionDurationGauge.With(labels).Set(peer.ConnectionStatus.Duration.Seconds())
+	}
+
+	var missingPeers int
+
+	for _, nodeID := range parseExpectedPeers(ExpectedPeers) {
+		var connected float64
+		if connectedPeers[nodeID] {
+			connected = 1
+		} else {
+			missingPeers++
+		}
+
+		peerExpectedConnectedGauge.With(prometheus.Labels{"node_id": nodeID}).Set(connected)
+	}
+
+	peersExpectedMissingGauge.Set(float64(missingPeers))
+
+	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	h.ServeHTTP(w, r)
+	sublogger.Info().
+		Str("method", "GET").
+		Str("endpoint", "/metrics/peers").
+		Float64("request-time", time.Since(requestStart).Seconds()).
+		Msg("Request processed")
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	PriceSourceHTTP = "http"
+	PriceSourceFile = "file"
+)
+
+// PricePath is the path to the denom price in the prices JSON.
+type PricePath struct {
+	Denom string `mapstructure:"denom"`
+	Path  string `mapstructure:"path"`
+}
+
+// PriceSource returns the price of one display unit (e.g. ATOM, not uatom) per denom,
+// the denoms are the same as in the denom label of the other metrics.
+type PriceSource interface {
+	GetPrices() (map[string]float64, error)
+}
+
+// HTTPPriceSource takes the prices from the JSON returned by the URL, like the one from
+// https://api.coingecko.com/api/v3/simple/price?ids=cosmos&vs_currencies=usd,
+// with the path to the price configured per display denom ("atom" = "cosmos.usd").
+type HTTPPriceSource struct {
+	URL    string
+	Paths  map[string]string
+	Client *http.Client
+}
+
+func (s HTTPPriceSource) GetPrices() (map[string]float64, error) {
+	response, err := s.Client.Get(s.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got HTTP status %d", response.StatusCode)
+	}
+
+	content, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return getPricesFromJSON(content, s.Paths)
+}
+
+// FilePriceSource takes the prices from the local JSON file, which is useful if the prices
+// are fetched by some other tool or don't change at all. Without paths configured,
+// the file is expected to be an object with denoms as keys and prices as values.
+type FilePriceSource struct {
+	Path  string
+	Paths map[string]string
+}
+
+func (s FilePriceSource) GetPrices() (map[string]float64, error) {
+	content, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	return getPricesFromJSON(content, s.Paths)
+}
+
+func getPricesFromJSON(content []byte, paths map[string]string) (map[string]float64, error) {
+	var data interface{}
+	if err := json.Unmarshal(content, &data); err != nil {
+		return nil, err
+	}
+
+	if len(paths) == 0 {
+		object, ok := data.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected an object with prices per denom")
+		}
+
+		paths = make(map[string]string, len(object))
+		for denom := range object {
+			paths[denom] = denom
+		}
+	}
+
+	prices := make(map[string]float64, len(paths))
+
+	for denom, path := range paths {
+		rawPrice, err := getJSONPath(data, path)
+		if err != nil {
+			log.Warn().Str("denom", denom).Err(err).Msg("Could not get price")
+			continue
+		}
+
+		price, err := jsonValueToFloat(rawPrice)
+		if err != nil {
+			log.Warn().Str("denom", denom).Err(err).Msg("Could not parse price")
+			continue
+		}
+
+		prices[denom] = price
+	}
+
+	return prices, nil
+}
+
+type denomPrice struct {
+	Price   float64
+	Updated time.Time
+}
+
+// PricesTracker polls the prices source in background, keeping the last known price
+// if the source is unavailable, so the staleness can be alerted on. The configured denoms
+// are reported as stale until their price is fetched for the first time.
+type PricesTracker struct {
+	source     PriceSource
+	denoms     []string
+	staleAfter time.Duration
+
+	mutex  sync.Mutex
+	prices map[string]denomPrice
+}
+
+func NewPricesTracker(source PriceSource, denoms []string, staleAfter time.Duration) *PricesTracker {
+	return &PricesTracker{
+		source:     source,
+		denoms:     denoms,
+		staleAfter: staleAfter,
+		prices:     make(map[string]denomPrice),
+	}
+}
+
+func (t *PricesTracker) Start(interval time.Duration) {
+	for {
+		if err := t.update(); err != nil {
+			log.Error().Err(err).Msg("Could not update prices")
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+func (t *PricesTracker) update() error {
+	queryStart := time.Now()
+
+	prices, err := t.source.GetPrices()
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for denom, price := range prices {
+		t.prices[denom] = denomPrice{Price: price, Updated: time.Now()}
+	}
+
+	log.Debug().
+		Int("prices", len(prices)).
+		Float64("request-time", time.Since(queryStart).Seconds()).
+		Msg("Updated prices")
+
+	return nil
+}
+
+func (t *PricesTracker) getPrices() map[string]denomPrice {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	prices := make(map[string]denomPrice, len(t.prices))
+	for denom, price := range t.prices {
+		prices[denom] = price
+	}
+
+	return prices
+}
+
+func PricesHandler(w http.ResponseWriter, r *http.Request, pricesTracker *PricesTracker) {
+	requestStart := time.Now()
+
+	sublogger := log.With().
+		Str("request-id", uuid.New().String()).
+		Logger()
+
+	priceGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_price",
+			Help:        "Price of one display unit of the denom",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"denom", "currency"},
+	)
+
+	priceLastUpdateGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_price_last_update",
+			Help:        "Time of the last successful price update, in unix seconds",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"denom"},
+	)
+
+	priceStaleGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_price_stale",
+			Help:        "1 if the price wasn't updated for longer than --price-stale-after, 0 otherwise",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"denom"},
+	)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(priceGauge)
+	registry.MustRegister(priceLastUpdateGauge)
+	registry.MustRegister(priceStaleGauge)
+
+	prices := pricesTracker.getPrices()
+
+	for denom, price := range prices {
+		priceGauge.With(prometheus.Labels{
+			"denom":    denom,
+			"currency": PriceCurrency,
+		}).Set(price.Price)
+
+		priceLastUpdateGauge.With(prometheus.Labels{"denom": denom}).Set(float64(price.Updated.Unix()))
+
+		var stale float64
+		if time.Since(price.Updated) > pricesTracker.staleAfter {
+			stale = 1
+		}
+
+		priceStaleGauge.With(prometheus.Labels{"denom": denom}).Set(stale)
+	}
+
+	for _, denom := range pricesTracker.denoms {
+		if _, ok := prices[denom]; !ok {
+			priceStaleGauge.With(prometheus.Labels{"denom": denom}).Set(1)
+		}
+	}
+
+	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	h.ServeHTTP(w, r)
+	sublogger.Info().
+		Str("method", "GET").
+		Str("endpoint", "/metrics/prices").
+		Float64("request-time", time.Since(requestStart).Seconds()).
+		Msg("Request processed")
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	tmrpc "github.com/tendermint/tendermint/rpc/client/http"
+	"google.golang.org/grpc"
+)
+
+// Tendermint returns at most 20 block headers per BlockchainInfo request
+const blockchainInfoLimit = 20
+
+type blockProposal struct {
+	Height   int64
+	Time     time.Time
+	Proposer string
+}
+
+// ProposalsTracker keeps the proposers of the latest blocks in memory,
+// as there's no way to get the proposals stats from the chain itself.
+type ProposalsTracker struct {
+	client *tmrpc.HTTP
+	window int64
+
+	mutex         sync.Mutex
+	proposals     []blockProposal
+	lastProposals map[string]blockProposal
+}
+
+func NewProposalsTracker(client *tmrpc.HTTP, window int64) *ProposalsTracker {
+	return &ProposalsTracker{
+		client:        client,
+		window:        window,
+		lastProposals: make(map[string]blockProposal),
+	}
+}
+
+func (t *ProposalsTracker) Start(interval time.Duration) {
+	for {
+		if err := t.update(); err != nil {
+			log.Error().Err(err).Msg("Could not update block proposals")
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+func (t *ProposalsTracker) update() error {
+	queryStart := time.Now()
+
+	status, err := t.client.Status(context.Background())
+	if err != nil {
+		return err
+	}
+
+	latestHeight := status.SyncInfo.LatestBlockHeight
+
+	t.mutex.Lock()
+	fromHeight := latestHeight - t.window + 1
+	if len(t.proposals) > 0 && t.proposals[len(t.proposals)-1].Height+1 > fromHeight {
+		fromHeight = t.proposals[len(t.proposals)-1].Height + 1
+	}
+	t.mutex.Unlock()
+
+	if fromHeight < 1 {
+		fromHeight = 1
+	}
+
+	for minHeight := fromHeight; minHeight <= latestHeight; minHeight += blockchainInfoLimit {
+		maxHeight := minHeight + blockchainInfoLimit - 1
+		if maxHeight > latestHeight {
+			maxHeight = latestHeight
+		}
+
+		response, err := t.client.BlockchainInfo(context.Background(), minHeight, maxHeight)
+		if err != nil {
+			return err
+		}
+
+		// block metas are returned from the newest to the oldest
+		newProposals := make([]blockProposal, len(response.BlockMetas))
+		for index, blockMeta := range response.BlockMetas {
+			newProposals[len(response.BlockMetas)-index-1] = blockProposal{
+				Height:   blockMeta.Header.Height,
+				Time:     blockMeta.Header.Time,
+				Proposer: blockMeta.Header.ProposerAddress.String(),
+			}
+		}
+
+		t.addProposals(newProposals)
+	}
+
+	log.Debug().
+		Int64("from", fromHeight).
+		Int64("to", latestHeight).
+		Float64("request-time", time.Since(queryStart).Seconds()).
+		Msg("Updated block proposals")
+
+	return nil
+}
+
+func (t *ProposalsTracker) addProposals(proposals []blockProposal) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, proposal := range proposals {
+		// the node might have pruned some blocks, so not relying on them being sequential
+		if len(t.proposals) > 0 && proposal.Height <= t.proposals[len(t.proposals)-1].Height {
+			continue
+		}
+
+		t.proposals = append(t.proposals, proposal)
+		t.lastProposals[proposal.Proposer] = proposal
+	}
+
+	if int64(len(t.proposals)) > t.window {
+		t.proposals = t.proposals[int64(len(t.proposals))-t.window:]
+	}
+}
+
+// getProposals returns the amount of blocks in the window, the blocks proposed
+// by each validator within it, and the last proposal of each validator since startup.
+func (t *ProposalsTracker) getProposals() (int, map[string]int, map[string]blockProposal) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	proposed := make(map[string]int)
+	for _, proposal := range t.proposals {
+		proposed[proposal.Proposer]++
+	}
+
+	lastProposals := make(map[string]blockProposal, len(t.lastProposals))
+	for proposer, proposal := range t.lastProposals {
+		lastProposals[proposer] = proposal
+	}
+
+	return len(t.proposals), proposed, lastProposals
+}
+
+func ProposalsHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.ClientConn, tracker *ProposalsTracker) {
+	requestStart := time.Now()
+
+	sublogger := log.With().
+		Str("request-id", uuid.New().String()).
+		Logger()
+
+	proposalsWindowGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_proposals_window",
+			Help:        "Amount of latest blocks the proposals are counted within",
+			ConstLabels: ConstLabels,
+		},
+	)
+
+	proposalsProposedGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_proposals_proposed",
+			Help:        "Blocks proposed by the Cosmos-based blockchain validator within the window",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker", "consensus_address_hex"},
+	)
+
+	proposalsExpectedGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_proposals_expected",
+			Help:        "Blocks expected to be proposed by the Cosmos-based blockchain validator within the window, based on its current voting power",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker", "consensus_address_hex"},
+	)
+
+	proposalsLastHeightGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_proposals_last_height",
+			Help:        "Height of the last block proposed by the Cosmos-based blockchain validator since the exporter start",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker", "consensus_address_hex"},
+	)
+
+	proposalsLastTimeGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_proposals_last_time",
+			Help:        "Time of the last block proposed by the Cosmos-based blockchain validator since the exporter start, in unix seconds",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker", "consensus_address_hex"},
+	)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(proposalsWindowGauge)
+	registry.MustRegister(proposalsProposedGauge)
+	registry.MustRegister(proposalsExpectedGauge)
+	registry.MustRegister(proposalsLastHeightGauge)
+	registry.MustRegister(proposalsLastTimeGauge)
+
+	sublogger.Debug().Msg("Started querying validators")
+	queryStart := time.Now()
+
+	// the proposals are tracked by the consensus address, so they are served even without
+	// the validators, only the expected proposals depend on them
+	validators, err := getValidatorsByConsensusAddress(grpcConn)
+	if err != nil {
+		sublogger.Error().Err(err).Msg("Could not get validators")
+	} else {
+		sublogger.Debug().
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying validators")
+	}
+
+	blocksCount, proposed, lastProposals := tracker.getProposals()
+	proposalsWindowGauge.Set(float64(blocksCount))
+
+	// only bonded validators have voting power
+	votingPowers := make(map[string]float64)
+	var totalVotingPower float64
+
+	for consensusAddress, validator := range validators {
+		if !validator.IsBonded() {
+			continue
+		}
+
+		if value, err := strconv.ParseFloat(validator.Tokens.String(), 64); err != nil {
+			sublogger.Error().
+				Str("address", validator.OperatorAddress).
+				Err(err).
+				Msg("Could not parse validator tokens")
+		} else {
+			votingPowers[consensusAddress] = value
+			totalVotingPower += value
+		}
+	}
+
+	for consensusAddress, validator := range validators {
+		labels := prometheus.Labels{
+			"address":               validator.OperatorAddress,
+			"moniker":               validator.Description.Moniker,
+			"consensus_address_hex": consensusAddress,
+		}
+
+		if votingPower, ok := votingPowers[consensusAddress]; ok && totalVotingPower > 0 {
+			// Tendermint picks proposers round-robin weighted by voting power
+			proposalsExpectedGauge.With(labels).Set(float64(blocksCount) * votingPower / totalVotingPower)
+			proposalsProposedGauge.With(labels).Set(float64(proposed[consensusAddress]))
+		} else if count, ok := proposed[consensusAddress]; ok {
+			// the validator might have left the active set within the window
+			proposalsProposedGauge.With(labels).Set(float64(count))
+		}
+
+		if proposal, ok := lastProposals[consensusAddress]; ok {
+			proposalsLastHeightGauge.With(labels).Set(float64(proposal.Height))
+			proposalsLastTimeGauge.With(labels).Set(float64(proposal.Time.Unix()))
+		}
+	}
+
+	// proposers not found among the validators, or all of them if the validators query failed
+	unknownProposers := make(map[string]bool)
+	for consensusAddress := range proposed {
+		if _, ok := validators[consensusAddress]; !ok {
+			unknownProposers[consensusAddress] = true
+		}
+	}
+
+	for consensusAddress := range lastProposals {
+		if _, ok := validators[consensusAddress]; !ok {
+			unknownProposers[consensusAddress] = true
+		}
+	}
+
+	for consensusAddress := range unknownProposers {
+		labels := prometheus.Labels{
+			"address":               "",
+			"moniker":               "",
+			"consensus_address_hex": consensusAddress,
+		}
+
+		if count, ok := proposed[consensusAddress]; ok {
+			proposalsProposedGauge.With(labels).Set(float64(count))
+		}
+
+		if proposal, ok := lastProposals[consensusAddress]; ok {
+			proposalsLastHeightGauge.With(labels).Set(float64(proposal.Height))
+			proposalsLastTimeGauge.With(labels).Set(float64(proposal.Time.Unix()))
+		}
+	}
+
+	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	h.ServeHTTP(w, r)
+	sublogger.Info().
+		Str("method", "GET").
+		Str("endpoint", "/metrics/proposals").
+		Float64("request-time", time.Since(requestStart).Seconds()).
+		Msg("Request processed")
+}
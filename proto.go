@@ -0,0 +1,21 @@
+package main
+
+import (
+	"github.com/golang/protobuf/proto"
+)
+
+// The SDK version we depend on doesn't have some of the modules newer chains have,
+// so their messages are declared here by hand. They only have the fields we need,
+// the field numbers must match the ones in the original .proto files.
+// gRPC marshals them using the struct tags, so no generated code is needed.
+
+// protoCoin is the same as sdk.Coin, which can't be used inside such messages
+// because of its custom amount type.
+type protoCoin struct {
+	Denom  string `protobuf:"bytes,1,opt,name=denom,proto3"`
+	Amount string `protobuf:"bytes,2,opt,name=amount,proto3"`
+}
+
+func (m *protoCoin) Reset()         { *m = protoCoin{} }
+func (m *protoCoin) String() string { return proto.CompactTextString(m) }
+func (*protoCoin) ProtoMessage()    {}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog"
+	abcitypes "github.com/tendermint/tendermint/abci/types"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	tmrpc "github.com/tendermint/tendermint/rpc/client/http"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+const (
+	// TransfersEventsCoin is for the coin_received and coin_spent events, emitted since cosmos-sdk 0.44
+	TransfersEventsCoin = "coin"
+	// TransfersEventsTransfer is for the transfer events, emitted by all versions,
+	// but they don't include the fees and tokens minted or burned by modules
+	TransfersEventsTransfer = "transfer"
+
+	transfersSubscriber = "cosmos-exporter"
+	// how long to wait before resubscribing if the subscription failed
+	transfersResubscribeInterval = 10 * time.Second
+	// the client drops the events if the channel is full, and all the transactions are received
+	transfersEventsCapacity = 1000
+	// the only way to know the client dropped an event, as it doesn't report it otherwise
+	transfersEventDroppedMessage = "wanted to publish ResultEvent, but out channel is full"
+)
+
+// transfersDirection describes the event attribute holding the watched address for
+// the received or sent tokens, the amount attribute follows it in the same event.
+type transfersDirection struct {
+	EventType string
+	Attribute string
+	Counter   *prometheus.CounterVec
+}
+
+// TransfersWatcher subscribes to the Tendermint websocket for all the transactions and blocks and
+// counts the tokens the watched addresses sent and received, which can't be calculated from the
+// balances polling, as there might be lots of transfers between scrapes. The same two subscriptions
+// are used for all the addresses, as Tendermint limits the subscriptions per client (5 by default).
+// The counters start from zero at each exporter restart.
+type TransfersWatcher struct {
+	client        *tmrpc.HTTP
+	addresses     map[string]bool
+	directions    []transfersDirection
+	subscribed    *prometheus.GaugeVec
+	receivedTotal *prometheus.CounterVec
+	sentTotal     *prometheus.CounterVec
+	droppedTotal  prometheus.Counter
+	missedTotal   prometheus.Counter
+	lastHeight    int64
+}
+
+// transfersClientLogger passes the Tendermint websocket client logs to zerolog
+// and counts the events the client dropped.
+type transfersClientLogger struct {
+	logger       zerolog.Logger
+	droppedTotal prometheus.Counter
+}
+
+func (l transfersClientLogger) Debug(msg string, keyvals ...interface{}) {
+	l.logger.Trace().Fields(transfersLogFields(keyvals)).Msg(msg)
+}
+
+func (l transfersClientLogger) Info(msg string, keyvals ...interface{}) {
+	l.logger.Debug().Fields(transfersLogFields(keyvals)).Msg(msg)
+}
+
+func (l transfersClientLogger) Error(msg string, keyvals ...interface{}) {
+	if msg == transfersEventDroppedMessage {
+		// not logging the fields, as they contain the whole event
+		l.droppedTotal.Inc()
+		l.logger.Warn().Msg("Dropped a transfers event, the channel is full")
+		return
+	}
+
+	l.logger.Error().Fields(transfersLogFields(keyvals)).Msg(msg)
+}
+
+func (l transfersClientLogger) With(keyvals ...interface{}) tmlog.Logger {
+	l.logger = l.logger.With().Fields(transfersLogFields(keyvals)).Logger()
+	return l
+}
+
+func transfersLogFields(keyvals []interface{}) map[string]interface{} {
+	fields := make(map[string]interface{}, len(keyvals)/2)
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		fields[fmt.Sprint(keyvals[i])] = keyvals[i+1]
+	}
+
+	return fields
+}
+
+func NewTransfersWatcher(client *tmrpc.HTTP, addresses []string, eventsType string) *TransfersWatcher {
+	watcher := &TransfersWatcher{
+		client:    client,
+		addresses: make(map[string]bool, len(addresses)),
+		subscribed: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_transfers_subscribed",
+				Help:        "1 if the exporter is subscribed to the transactions (events=tx) or blocks (events=block) events, 0 otherwise. Transfers are not counted while it's 0",
+				ConstLabels: ConstLabels,
+			},
+			[]string{"events"},
+		),
+		receivedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        "cosmos_transfers_received_total",
+				Help:        "Amount of tokens received by the address since the exporter start",
+				ConstLabels: ConstLabels,
+			},
+			[]string{"address", "denom"},
+		),
+		sentTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        "cosmos_transfers_sent_total",
+				Help:        "Amount of tokens sent by the address since the exporter start",
+				ConstLabels: ConstLabels,
+			},
+			[]string{"address", "denom"},
+		),
+		droppedTotal: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name:        "cosmos_transfers_dropped_events_total",
+				Help:        "Amount of transactions and blocks events dropped by the Tendermint client as they were not processed in time, the transfers in them are not counted",
+				ConstLabels: ConstLabels,
+			},
+		),
+		missedTotal: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name:        "cosmos_transfers_missed_blocks_total",
+				Help:        "Amount of blocks the exporter received no events for while subscribed, like during the websocket reconnects, the transfers in them are not counted",
+				ConstLabels: ConstLabels,
+			},
+		),
+	}
+
+	for _, address := range addresses {
+		watcher.addresses[address] = true
+	}
+
+	if eventsType == TransfersEventsTransfer {
+		watcher.directions = []transfersDirection{
+			{EventType: "transfer", Attribute: "recipient", Counter: watcher.receivedTotal},
+			{EventType: "transfer", Attribute: "sender", Counter: watcher.sentTotal},
+		}
+	} else {
+		watcher.directions = []transfersDirection{
+			{EventType: "coin_received", Attribute: "receiver", Counter: watcher.receivedTotal},
+			{EventType: "coin_spent", Attribute: "spender", Counter: watcher.sentTotal},
+		}
+	}
+
+	return watcher
+}
+
+func (w *TransfersWatcher) Start() {
+	w.client.SetLogger(transfersClientLogger{
+		logger:       log.With().Str("component", "tendermint-websocket").Logger(),
+		droppedTotal: w.droppedTotal,
+	})
+
+	// the websocket is only started here, as nothing else needs it
+	if err := w.client.Start(); err != nil {
+		log.Error().Err(err).Msg("Could not start Tendermint websocket")
+		return
+	}
+
+	// the fees and the tokens minted, burned or sent by modules in BeginBlock and EndBlock
+	// (like the rewards or the unbonded delegations) are not in the transactions events.
+	// NewBlockHeader has the same block events as NewBlock, but without the transactions
+	go w.subscribe("tm.event='NewBlockHeader'", "block")
+
+	// filtering by the addresses in the query would take two subscriptions per address
+	w.subscribe("tm.event='Tx'", "tx")
+}
+
+func (w *TransfersWatcher) subscribe(query string, eventsLabel string) {
+	sublogger := log.With().
+		Str("query", query).
+		Logger()
+
+	subscribed := w.subscribed.With(prometheus.Labels{"events": eventsLabel})
+	subscribed.Set(0)
+
+	for {
+		events, err := w.client.Subscribe(
+			context.Background(),
+			transfersSubscriber,
+			query,
+			transfersEventsCapacity,
+		)
+		if err != nil {
+			sublogger.Error().Err(err).Msg("Could not subscribe to transfers")
+			time.Sleep(transfersResubscribeInterval)
+			continue
+		}
+
+		sublogger.Debug().Msg("Subscribed to transfers")
+		subscribed.Set(1)
+
+		for event := range events {
+			switch data := event.Data.(type) {
+			case tmtypes.EventDataTx:
+				w.observeEvents(data.Result.Events)
+			case tmtypes.EventDataNewBlockHeader:
+				w.observeHeight(data.Header.Height)
+				w.observeEvents(data.ResultBeginBlock.Events)
+				w.observeEvents(data.ResultEndBlock.Events)
+			}
+		}
+
+		subscribed.Set(0)
+		sublogger.Warn().Msg("Transfers subscription closed, resubscribing")
+
+		if err := w.client.Unsubscribe(context.Background(), transfersSubscriber, query); err != nil {
+			sublogger.Debug().Err(err).Msg("Could not unsubscribe from transfers")
+		}
+
+		time.Sleep(transfersResubscribeInterval)
+	}
+}
+
+// observeHeight counts the blocks skipped since the previous one. The client resubscribes
+// on the websocket reconnect by itself, keeping the same channel open, so the subscription
+// looks uninterrupted, and the skipped heights are the only sign of the events lost meanwhile.
+// It's only called from the blocks subscription, so it doesn't need a lock.
+func (w *TransfersWatcher) observeHeight(height int64) {
+	if w.lastHeight != 0 && height > w.lastHeight+1 {
+		w.missedTotal.Add(float64(height - w.lastHeight - 1))
+	}
+
+	w.lastHeight = height
+}
+
+// observeEvents goes through the event attributes in order, as older chains merge the events
+// of the same type into one, so the amount belongs to the latest address attribute before it.
+func (w *TransfersWatcher) observeEvents(events []abcitypes.Event) {
+	for _, direction := range w.directions {
+		for _, event := range events {
+			if event.Type != direction.EventType {
+				continue
+			}
+
+			var address string
+
+			for _, attribute := range event.Attributes {
+				switch string(attribute.Key) {
+				case direction.Attribute:
+					address = ""
+					if w.addresses[string(attribute.Value)] {
+						address = string(attribute.Value)
+					}
+				case "amount":
+					if address != "" {
+						w.observeAmount(address, string(attribute.Value), direction.Counter)
+					}
+				}
+			}
+		}
+	}
+}
+
+func (w *TransfersWatcher) observeAmount(address string, amount string, counter *prometheus.CounterVec) {
+	// the amount is empty if no coins were transferred
+	if strings.TrimSpace(amount) == "" {
+		return
+	}
+
+	coins, err := sdk.ParseCoinsNormalized(amount)
+	if err != nil {
+		log.Error().
+			Str("address", address).
+			Str("amount", amount).
+			Err(err).
+			Msg("Could not parse transfer amount")
+		return
+	}
+
+	for _, coin := range coins {
+		// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
+		value, err := strconv.ParseFloat(coin.Amount.String(), 64)
+		if err != nil {
+			log.Error().
+				Str("address", address).
+				Err(err).
+				Msg("Could not parse transfer amount")
+			continue
+		}
+
+		denom, value := getCoinValue(coin.Denom, value)
+		counter.With(prometheus.Labels{
+			"address": address,
+			"denom":   denom,
+		}).Add(value)
+	}
+}
+
+func TransfersHandler(w http.ResponseWriter, r *http.Request, transfersWatcher *TransfersWatcher) {
+	requestStart := time.Now()
+
+	sublogger := log.With().
+		Str("request-id", uuid.New().String()).
+		Logger()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(transfersWatcher.subscribed)
+	registry.MustRegister(transfersWatcher.receivedTotal)
+	registry.MustRegister(transfersWatcher.sentTotal)
+	registry.MustRegister(transfersWatcher.droppedTotal)
+	registry.MustRegister(transfersWatcher.missedTotal)
+
+	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	h.ServeHTTP(w, r)
+	sublogger.Info().
+		Str("method", "GET").
+		Str("endpoint", "/metrics/transfers").
+		Float64("request-time", time.Since(requestStart).Seconds()).
+		Msg("Request processed")
+}
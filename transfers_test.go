@@ -0,0 +1,135 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	abcitypes "github.com/tendermint/tendermint/abci/types"
+)
+
+const (
+	testTransfersAddress = "cosmos1watched"
+	testTransfersOther   = "cosmos1other"
+)
+
+func testTransfersEvent(eventType string, keyvals ...string) abcitypes.Event {
+	event := abcitypes.Event{Type: eventType}
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		event.Attributes = append(event.Attributes, abcitypes.EventAttribute{
+			Key:   []byte(keyvals[i]),
+			Value: []byte(keyvals[i+1]),
+		})
+	}
+
+	return event
+}
+
+func TestTransfersWatcherObserveEvents(t *testing.T) {
+	defer func(denom, bondDenom string, coefficient float64) {
+		Denom, BondDenom, DenomCoefficient = denom, bondDenom, coefficient
+	}(Denom, BondDenom, DenomCoefficient)
+
+	Denom = "atom"
+	BondDenom = "uatom"
+	DenomCoefficient = 1000000
+
+	tests := []struct {
+		name       string
+		eventsType string
+		events     []abcitypes.Event
+		received   map[string]float64
+		sent       map[string]float64
+	}{
+		{
+			"bond denom is converted",
+			TransfersEventsCoin,
+			[]abcitypes.Event{
+				testTransfersEvent("coin_received", "receiver", testTransfersAddress, "amount", "1500000uatom"),
+			},
+			map[string]float64{"atom": 1.5},
+			map[string]float64{},
+		},
+		{
+			"other denoms are not converted",
+			TransfersEventsCoin,
+			[]abcitypes.Event{
+				testTransfersEvent("coin_spent", "spender", testTransfersAddress, "amount", "1000000uatom,7uosmo"),
+			},
+			map[string]float64{},
+			map[string]float64{"atom": 1, "uosmo": 7},
+		},
+		{
+			"merged events",
+			TransfersEventsCoin,
+			[]abcitypes.Event{
+				testTransfersEvent(
+					"coin_received",
+					"receiver", testTransfersOther, "amount", "100uatom",
+					"receiver", testTransfersAddress, "amount", "2000000uatom",
+					"receiver", testTransfersOther, "amount", "300uatom",
+					"receiver", testTransfersAddress, "amount", "500000uatom",
+				),
+			},
+			map[string]float64{"atom": 2.5},
+			map[string]float64{},
+		},
+		{
+			"unwatched addresses and empty amounts",
+			TransfersEventsCoin,
+			[]abcitypes.Event{
+				testTransfersEvent("coin_received", "receiver", testTransfersOther, "amount", "100uatom"),
+				testTransfersEvent("coin_spent", "spender", testTransfersAddress, "amount", ""),
+				testTransfersEvent("transfer", "recipient", testTransfersAddress, "sender", testTransfersOther, "amount", "100uatom"),
+			},
+			map[string]float64{},
+			map[string]float64{},
+		},
+		{
+			"transfer events",
+			TransfersEventsTransfer,
+			[]abcitypes.Event{
+				testTransfersEvent("transfer", "recipient", testTransfersAddress, "sender", testTransfersOther, "amount", "3000000uatom"),
+				testTransfersEvent("transfer", "recipient", testTransfersOther, "sender", testTransfersAddress, "amount", "1000000uatom"),
+				testTransfersEvent("coin_received", "receiver", testTransfersAddress, "amount", "100uatom"),
+			},
+			map[string]float64{"atom": 3},
+			map[string]float64{"atom": 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			watcher := NewTransfersWatcher(nil, []string{testTransfersAddress}, test.eventsType)
+			watcher.observeEvents(test.events)
+
+			checkTransfersCounter(t, "received", watcher.receivedTotal, test.received)
+			checkTransfersCounter(t, "sent", watcher.sentTotal, test.sent)
+		})
+	}
+}
+
+func checkTransfersCounter(t *testing.T, name string, counter *prometheus.CounterVec, expected map[string]float64) {
+	if actual := testutil.CollectAndCount(counter); actual != len(expected) {
+		t.Errorf("expected %d %s denoms, got %d", len(expected), name, actual)
+	}
+
+	for denom, value := range expected {
+		labels := prometheus.Labels{"address": testTransfersAddress, "denom": denom}
+		if actual := testutil.ToFloat64(counter.With(labels)); actual != value {
+			t.Errorf("expected %f %s %s, got %f", value, name, denom, actual)
+		}
+	}
+}
+
+func TestTransfersWatcherObserveHeight(t *testing.T) {
+	watcher := NewTransfersWatcher(nil, nil, TransfersEventsCoin)
+
+	for _, height := range []int64{100, 101, 105, 106, 106, 110} {
+		watcher.observeHeight(height)
+	}
+
+	if actual := testutil.ToFloat64(watcher.missedTotal); actual != 6 {
+		t.Errorf("expected 6 missed blocks, got %f", actual)
+	}
+}
@@ -2,22 +2,33 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
 	"github.com/cosmos/cosmos-sdk/simapp"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	querytypes "github.com/cosmos/cosmos-sdk/types/query"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 	distributiontypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
 	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
 	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func ValidatorHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.ClientConn) {
@@ -45,6 +56,43 @@ func ValidatorHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cli
 		[]string{"address", "moniker", "denom", "delegated_by"},
 	)
 
+	validatorDelegatorsCountGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_delegators_count",
+			Help:        "Amount of delegators of the Cosmos-based blockchain validator",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorDelegationsSizeHistogram := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:        "cosmos_validator_delegations_size",
+			Help:        "Sizes of delegations of the Cosmos-based blockchain validator",
+			ConstLabels: ConstLabels,
+			Buckets:     prometheus.ExponentialBuckets(1, 10, 8),
+		},
+		[]string{"address", "moniker", "denom"},
+	)
+
+	validatorDelegationsMedianGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_delegations_median",
+			Help:        "Median delegation of the Cosmos-based blockchain validator",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker", "denom"},
+	)
+
+	validatorDelegationsMeanGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_delegations_mean",
+			Help:        "Mean delegation of the Cosmos-based blockchain validator",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker", "denom"},
+	)
+
 	validatorTokensGauge := prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name:        "cosmos_validator_tokens",
@@ -63,6 +111,69 @@ func ValidatorHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cli
 		[]string{"address", "moniker", "denom"},
 	)
 
+	validatorSelfDelegationGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_self_delegation",
+			Help:        "Tokens delegated to the Cosmos-based blockchain validator by its operator",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker", "denom"},
+	)
+
+	validatorMinSelfDelegationGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_min_self_delegation",
+			Help:        "Self declared minimum self delegation of the Cosmos-based blockchain validator",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker", "denom"},
+	)
+
+	validatorSelfDelegationMarginGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_self_delegation_margin",
+			Help:        "Tokens the self delegation of the Cosmos-based blockchain validator can decrease by before it gets unbonded",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker", "denom"},
+	)
+
+	validatorOperatorBalanceGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_operator_balance",
+			Help:        "Balance of the Cosmos-based blockchain validator operator wallet",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker", "denom"},
+	)
+
+	validatorOperatorRewardsGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_operator_rewards",
+			Help:        "Pending rewards of the Cosmos-based blockchain validator operator wallet from all its delegations",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker", "denom"},
+	)
+
+	validatorWithdrawAddressGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_withdraw_address",
+			Help:        "Withdraw address of the Cosmos-based blockchain validator operator wallet, always 1",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker", "withdraw_address"},
+	)
+
+	validatorWithdrawAddressMismatchGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_withdraw_address_mismatch",
+			Help:        "1 if the validator operator wallet withdraw address differs from the expected one (or from the wallet itself if not set), 0 otherwise",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker", "expected_withdraw_address"},
+	)
+
 	validatorCommissionRateGauge := prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name:        "cosmos_validator_commission_rate",
@@ -71,6 +182,16 @@ func ValidatorHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cli
 		},
 		[]string{"address", "moniker"},
 	)
+
+	validatorAPRGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_apr",
+			Help:        "Staking APR for delegators of the Cosmos-based blockchain validator, after its commission, 0 if it is jailed or not bonded",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
 	validatorCommissionGauge := prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name:        "cosmos_validator_commission",
@@ -116,6 +237,114 @@ func ValidatorHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cli
 		[]string{"address", "moniker"},
 	)
 
+	validatorMissedBlocksRatioGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_missed_blocks_ratio",
+			Help:        "Missed blocks of the Cosmos-based blockchain validator as a fraction of the signed blocks window",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorMissedBlocksLeftGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_missed_blocks_left",
+			Help:        "Amount of blocks the Cosmos-based blockchain validator can miss more before being jailed",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorTimeToJailGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_time_to_jail_seconds",
+			Help:        "Estimated time until the Cosmos-based blockchain validator is jailed if it stops signing now, in seconds",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorStartHeightGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_start_height",
+			Help:        "Height at which the Cosmos-based blockchain validator was first a candidate or was unjailed",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorIndexOffsetGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_index_offset",
+			Help:        "Signing info index offset of the Cosmos-based blockchain validator",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorJailedUntilGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_jailed_until",
+			Help:        "Timestamp until which the Cosmos-based blockchain validator is jailed, in unix seconds",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorJailedRemainingGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_jailed_remaining_seconds",
+			Help:        "Seconds left until the Cosmos-based blockchain validator can be unjailed, 0 if it can be already",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorTombstonedGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_tombstoned",
+			Help:        "1 if the Cosmos-based blockchain validator is tombstoned, 0 if no",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorCanUnjailGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_can_unjail",
+			Help:        "1 if the Cosmos-based blockchain validator is jailed and can be unjailed now, 0 if no",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorSlashesGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_slashes",
+			Help:        "Amount of times the Cosmos-based blockchain validator was slashed",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorSlashesFractionGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_slashes_fraction",
+			Help:        "Cumulative fraction of stake the Cosmos-based blockchain validator lost to slashes",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorLastSlashHeightGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_last_slash_height",
+			Help:        "Height of the last slash of the Cosmos-based blockchain validator",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
 	validatorRankGauge := prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name:        "cosmos_validator_rank",
@@ -152,20 +381,54 @@ func ValidatorHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cli
 		[]string{"address", "moniker"},
 	)
 
+	validatorInfoGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validator_info",
+			Help:        "Description and keys of the Cosmos-based blockchain validator, always 1",
+			ConstLabels: ConstLabels,
+		},
+		validatorInfoLabels,
+	)
+
 	registry := prometheus.NewRegistry()
 	registry.MustRegister(validatorDelegationsGauge)
+	registry.MustRegister(validatorDelegatorsCountGauge)
+	registry.MustRegister(validatorDelegationsSizeHistogram)
+	registry.MustRegister(validatorDelegationsMedianGauge)
+	registry.MustRegister(validatorDelegationsMeanGauge)
 	registry.MustRegister(validatorTokensGauge)
 	registry.MustRegister(validatorDelegatorSharesGauge)
+	registry.MustRegister(validatorSelfDelegationGauge)
+	registry.MustRegister(validatorMinSelfDelegationGauge)
+	registry.MustRegister(validatorSelfDelegationMarginGauge)
+	registry.MustRegister(validatorOperatorBalanceGauge)
+	registry.MustRegister(validatorOperatorRewardsGauge)
+	registry.MustRegister(validatorWithdrawAddressGauge)
+	registry.MustRegister(validatorWithdrawAddressMismatchGauge)
 	registry.MustRegister(validatorCommissionRateGauge)
 	registry.MustRegister(validatorCommissionGauge)
+	registry.MustRegister(validatorAPRGauge)
 	registry.MustRegister(validatorRewardsGauge)
 	registry.MustRegister(validatorUnbondingsGauge)
 	registry.MustRegister(validatorRedelegationsGauge)
 	registry.MustRegister(validatorMissedBlocksGauge)
+	registry.MustRegister(validatorMissedBlocksRatioGauge)
+	registry.MustRegister(validatorMissedBlocksLeftGauge)
+	registry.MustRegister(validatorTimeToJailGauge)
+	registry.MustRegister(validatorStartHeightGauge)
+	registry.MustRegister(validatorIndexOffsetGauge)
+	registry.MustRegister(validatorJailedUntilGauge)
+	registry.MustRegister(validatorJailedRemainingGauge)
+	registry.MustRegister(validatorTombstonedGauge)
+	registry.MustRegister(validatorCanUnjailGauge)
+	registry.MustRegister(validatorSlashesGauge)
+	registry.MustRegister(validatorSlashesFractionGauge)
+	registry.MustRegister(validatorLastSlashHeightGauge)
 	registry.MustRegister(validatorRankGauge)
 	registry.MustRegister(validatorIsActiveGauge)
 	registry.MustRegister(validatorStatusGauge)
 	registry.MustRegister(validatorJailedGauge)
+	registry.MustRegister(validatorInfoGauge)
 
 	// doing this not in goroutine as we'll need the moniker value later
 	sublogger.Debug().
@@ -218,6 +481,20 @@ func ValidatorHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cli
 		}).Set(value / DenomCoefficient)
 	}
 
+	// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
+	if value, err := strconv.ParseFloat(validator.Validator.MinSelfDelegation.String(), 64); err != nil {
+		sublogger.Error().
+			Str("address", address).
+			Err(err).
+			Msg("Could not parse validator min self delegation")
+	} else {
+		validatorMinSelfDelegationGauge.With(prometheus.Labels{
+			"address": validator.Validator.OperatorAddress,
+			"moniker": validator.Validator.Description.Moniker,
+			"denom":   Denom,
+		}).Set(value / DenomCoefficient)
+	}
+
 	// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
 	if rate, err := strconv.ParseFloat(validator.Validator.Commission.CommissionRates.Rate.String(), 64); err != nil {
 		sublogger.Error().
@@ -249,6 +526,24 @@ func ValidatorHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cli
 		"moniker": validator.Validator.Description.Moniker,
 	}).Set(jailed)
 
+	encCfg := simapp.MakeTestEncodingConfig()
+	interfaceRegistry := encCfg.InterfaceRegistry
+
+	err = validator.Validator.UnpackInterfaces(interfaceRegistry) // Unpack interfaces, to populate the Anys' cached values
+	if err != nil {
+		sublogger.Error().
+			Str("address", address).
+			Err(err).
+			Msg("Could not get unpack validator inferfaces")
+	}
+
+	validatorInfoGauge.With(getValidatorInfoLabels(validator.Validator, sublogger)).Set(1)
+
+	// these are needed to calculate the jailing risk once all the queries are done
+	var signingInfo *slashingtypes.ValidatorSigningInfo
+	var slashingParams *slashingtypes.Params
+	var averageBlockTime time.Duration
+
 	var wg sync.WaitGroup
 
 	wg.Add(1)
@@ -257,47 +552,361 @@ func ValidatorHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cli
 
 		sublogger.Debug().
 			Str("address", address).
-			Msg("Started querying validator delegations")
+			Msg("Started querying validator delegations")
+		queryStart := time.Now()
+
+		stakingClient := stakingtypes.NewQueryClient(grpcConn)
+
+		// the count and the distribution need all the delegations, not only the first page
+		var delegationResponses stakingtypes.DelegationResponses
+		var nextKey []byte
+
+		for {
+			stakingRes, err := stakingClient.ValidatorDelegations(
+				context.Background(),
+				&stakingtypes.QueryValidatorDelegationsRequest{
+					ValidatorAddr: myAddress.String(),
+					Pagination: &querytypes.PageRequest{
+						Key:   nextKey,
+						Limit: Limit,
+					},
+				},
+			)
+			if err != nil {
+				sublogger.Error().
+					Str("address", address).
+					Err(err).
+					Msg("Could not get validator delegations")
+				return
+			}
+
+			delegationResponses = append(delegationResponses, stakingRes.DelegationResponses...)
+
+			if stakingRes.Pagination == nil || len(stakingRes.Pagination.NextKey) == 0 {
+				break
+			}
+
+			nextKey = stakingRes.Pagination.NextKey
+		}
+
+		sublogger.Debug().
+			Str("address", address).
+			Int("delegations", len(delegationResponses)).
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying validator delegations")
+
+		delegations := make([]delegatorSeries, 0, len(delegationResponses))
+
+		for _, delegation := range delegationResponses {
+			value, err := strconv.ParseFloat(delegation.Balance.Amount.String(), 64)
+			if err != nil {
+				log.Error().
+					Err(err).
+					Str("address", address).
+					Msg("Could not convert delegation entry")
+			} else {
+				delegations = append(delegations, delegatorSeries{
+					Labels: prometheus.Labels{
+						"moniker":      validator.Validator.Description.Moniker,
+						"address":      delegation.Delegation.ValidatorAddress,
+						"denom":        Denom,
+						"delegated_by": delegation.Delegation.DelegatorAddress,
+					},
+					Value: value / DenomCoefficient,
+				})
+			}
+		}
+
+		validatorDelegatorsCountGauge.With(prometheus.Labels{
+			"address": address,
+			"moniker": validator.Validator.Description.Moniker,
+		}).Set(float64(len(delegations)))
+
+		if len(delegations) > 0 {
+			values := make([]float64, len(delegations))
+			var sum float64
+
+			for index, delegation := range delegations {
+				values[index] = delegation.Value
+				sum += delegation.Value
+
+				validatorDelegationsSizeHistogram.With(prometheus.Labels{
+					"address": address,
+					"moniker": validator.Validator.Description.Moniker,
+					"denom":   Denom,
+				}).Observe(delegation.Value)
+			}
+
+			sort.Float64s(values)
+
+			// golang doesn't have a ternary operator, so we have to stick with this ugly solution
+			var median float64
+
+			if len(values)%2 == 0 {
+				median = (values[len(values)/2-1] + values[len(values)/2]) / 2
+			} else {
+				median = values[len(values)/2]
+			}
+
+			validatorDelegationsMedianGauge.With(prometheus.Labels{
+				"address": address,
+				"moniker": validator.Validator.Description.Moniker,
+				"denom":   Denom,
+			}).Set(median)
+
+			validatorDelegationsMeanGauge.With(prometheus.Labels{
+				"address": address,
+				"moniker": validator.Validator.Description.Moniker,
+				"denom":   Denom,
+			}).Set(sum / float64(len(values)))
+		}
+
+		for _, delegation := range limitDelegatorSeries(delegations, "delegated_by") {
+			validatorDelegationsGauge.With(delegation.Labels).Set(delegation.Value)
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		sublogger.Debug().
+			Str("address", address).
+			Msg("Started querying validator self delegation")
+		queryStart := time.Now()
+
+		// the operator account has the same bytes as the validator address, just another prefix
+		operatorAddress := sdk.AccAddress(myAddress)
+
+		stakingClient := stakingtypes.NewQueryClient(grpcConn)
+		stakingRes, err := stakingClient.Delegation(
+			context.Background(),
+			&stakingtypes.QueryDelegationRequest{
+				DelegatorAddr: operatorAddress.String(),
+				ValidatorAddr: myAddress.String(),
+			},
+		)
+
+		// the operator might have undelegated everything, that's not an error for us
+		var selfDelegation sdk.Int
+		if status.Code(err) == codes.NotFound {
+			selfDelegation = sdk.ZeroInt()
+		} else if err != nil {
+			sublogger.Error().
+				Str("address", address).
+				Err(err).
+				Msg("Could not get validator self delegation")
+			return
+		} else {
+			selfDelegation = stakingRes.DelegationResponse.Balance.Amount
+		}
+
+		sublogger.Debug().
+			Str("address", address).
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying validator self delegation")
+
+		if value, err := strconv.ParseFloat(selfDelegation.String(), 64); err != nil {
+			sublogger.Error().
+				Str("address", address).
+				Err(err).
+				Msg("Could not parse validator self delegation")
+		} else {
+			validatorSelfDelegationGauge.With(prometheus.Labels{
+				"address": validator.Validator.OperatorAddress,
+				"moniker": validator.Validator.Description.Moniker,
+				"denom":   Denom,
+			}).Set(value / DenomCoefficient)
+		}
+
+		// if it goes negative, the validator is going to be unbonded
+		margin := selfDelegation.Sub(validator.Validator.MinSelfDelegation)
+
+		if value, err := strconv.ParseFloat(margin.String(), 64); err != nil {
+			sublogger.Error().
+				Str("address", address).
+				Err(err).
+				Msg("Could not parse validator self delegation margin")
+		} else {
+			validatorSelfDelegationMarginGauge.With(prometheus.Labels{
+				"address": validator.Validator.OperatorAddress,
+				"moniker": validator.Validator.Description.Moniker,
+				"denom":   Denom,
+			}).Set(value / DenomCoefficient)
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		sublogger.Debug().
+			Str("address", address).
+			Msg("Started querying validator operator balance")
+		queryStart := time.Now()
+
+		bankClient := banktypes.NewQueryClient(grpcConn)
+		bankRes, err := bankClient.AllBalances(
+			context.Background(),
+			&banktypes.QueryAllBalancesRequest{Address: sdk.AccAddress(myAddress).String()},
+		)
+		if err != nil {
+			sublogger.Error().
+				Str("address", address).
+				Err(err).
+				Msg("Could not get validator operator balance")
+			return
+		}
+
+		sublogger.Debug().
+			Str("address", address).
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying validator operator balance")
+
+		for _, balance := range bankRes.Balances {
+			// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
+			if value, err := strconv.ParseFloat(balance.Amount.String(), 64); err != nil {
+				sublogger.Error().
+					Str("address", address).
+					Err(err).
+					Msg("Could not parse validator operator balance")
+			} else {
+				denom, value := getCoinValue(balance.Denom, value)
+				validatorOperatorBalanceGauge.With(prometheus.Labels{
+					"address": address,
+					"moniker": validator.Validator.Description.Moniker,
+					"denom":   denom,
+				}).Set(value)
+			}
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		sublogger.Debug().
+			Str("address", address).
+			Msg("Started querying validator operator rewards")
+		queryStart := time.Now()
+
+		distributionClient := distributiontypes.NewQueryClient(grpcConn)
+		distributionRes, err := distributionClient.DelegationTotalRewards(
+			context.Background(),
+			&distributiontypes.QueryDelegationTotalRewardsRequest{DelegatorAddress: sdk.AccAddress(myAddress).String()},
+		)
+		if err != nil {
+			sublogger.Error().
+				Str("address", address).
+				Err(err).
+				Msg("Could not get validator operator rewards")
+			return
+		}
+
+		sublogger.Debug().
+			Str("address", address).
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying validator operator rewards")
+
+		for _, reward := range distributionRes.Total {
+			// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
+			if value, err := strconv.ParseFloat(reward.Amount.String(), 64); err != nil {
+				sublogger.Error().
+					Str("address", address).
+					Err(err).
+					Msg("Could not parse validator operator rewards")
+			} else {
+				denom, value := getCoinValue(reward.Denom, value)
+				validatorOperatorRewardsGauge.With(prometheus.Labels{
+					"address": address,
+					"moniker": validator.Validator.Description.Moniker,
+					"denom":   denom,
+				}).Set(value)
+			}
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		sublogger.Debug().
+			Str("address", address).
+			Msg("Started querying validator withdraw address")
+		queryStart := time.Now()
+
+		distributionClient := distributiontypes.NewQueryClient(grpcConn)
+		distributionRes, err := distributionClient.DelegatorWithdrawAddress(
+			context.Background(),
+			&distributiontypes.QueryDelegatorWithdrawAddressRequest{DelegatorAddress: sdk.AccAddress(myAddress).String()},
+		)
+		if err != nil {
+			sublogger.Error().
+				Str("address", address).
+				Err(err).
+				Msg("Could not get validator withdraw address")
+			return
+		}
+
+		sublogger.Debug().
+			Str("address", address).
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying validator withdraw address")
+
+		validatorWithdrawAddressGauge.With(prometheus.Labels{
+			"address":          address,
+			"moniker":          validator.Validator.Description.Moniker,
+			"withdraw_address": distributionRes.WithdrawAddress,
+		}).Set(1)
+
+		expectedWithdrawAddress := getExpectedWithdrawAddress(r, sdk.AccAddress(myAddress).String())
+		validatorWithdrawAddressMismatchGauge.With(prometheus.Labels{
+			"address":                   address,
+			"moniker":                   validator.Validator.Description.Moniker,
+			"expected_withdraw_address": expectedWithdrawAddress,
+		}).Set(getWithdrawAddressMismatch(distributionRes.WithdrawAddress, expectedWithdrawAddress))
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		sublogger.Debug().
+			Str("address", address).
+			Msg("Started calculating staking APR")
 		queryStart := time.Now()
 
-		stakingClient := stakingtypes.NewQueryClient(grpcConn)
-		stakingRes, err := stakingClient.ValidatorDelegations(
-			context.Background(),
-			&stakingtypes.QueryValidatorDelegationsRequest{
-				ValidatorAddr: myAddress.String(),
-				Pagination: &querytypes.PageRequest{
-					Limit: Limit,
-				},
-			},
-		)
+		apr, err := APRSource.GetStakingAPR(grpcConn)
 		if err != nil {
 			sublogger.Error().
 				Str("address", address).
 				Err(err).
-				Msg("Could not get validator delegations")
+				Msg("Could not calculate staking APR")
 			return
 		}
 
 		sublogger.Debug().
 			Str("address", address).
 			Float64("request-time", time.Since(queryStart).Seconds()).
-			Msg("Finished querying validator delegations")
+			Msg("Finished calculating staking APR")
 
-		for _, delegation := range stakingRes.DelegationResponses {
-			value, err := strconv.ParseFloat(delegation.Balance.Amount.String(), 64)
-			if err != nil {
-				log.Error().
-					Err(err).
-					Str("address", address).
-					Msg("Could not convert delegation entry")
-			} else {
-				validatorDelegationsGauge.With(prometheus.Labels{
-					"moniker":      validator.Validator.Description.Moniker,
-					"address":      delegation.Delegation.ValidatorAddress,
-					"denom":        Denom,
-					"delegated_by": delegation.Delegation.DelegatorAddress,
-				}).Set(value / DenomCoefficient)
+		// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
+		if rate, err := strconv.ParseFloat(validator.Validator.Commission.CommissionRates.Rate.String(), 64); err != nil {
+			sublogger.Error().
+				Str("address", address).
+				Err(err).
+				Msg("Could not parse commission rate")
+		} else {
+			// jailed and not bonded validators don't earn any rewards
+			validatorAPR := apr * (1 - rate)
+			if validator.Validator.Jailed || !validator.Validator.IsBonded() {
+				validatorAPR = 0
 			}
+
+			validatorAPRGauge.With(prometheus.Labels{
+				"address": validator.Validator.OperatorAddress,
+				"moniker": validator.Validator.Description.Moniker,
+			}).Set(validatorAPR)
 		}
 	}()
 
@@ -390,6 +999,101 @@ func ValidatorHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cli
 		}
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		sublogger.Debug().
+			Str("address", address).
+			Msg("Started querying validator slashes")
+		queryStart := time.Now()
+
+		distributionClient := distributiontypes.NewQueryClient(grpcConn)
+
+		// the count and the cumulative fraction need all the slashes, not only the first page
+		var slashes []distributiontypes.ValidatorSlashEvent
+		var nextKey []byte
+
+		for {
+			distributionRes, err := distributionClient.ValidatorSlashes(
+				context.Background(),
+				&distributiontypes.QueryValidatorSlashesRequest{
+					ValidatorAddress: myAddress.String(),
+					StartingHeight:   0,
+					EndingHeight:     math.MaxUint64,
+					Pagination: &querytypes.PageRequest{
+						Key:   nextKey,
+						Limit: Limit,
+					},
+				},
+			)
+			if err != nil {
+				sublogger.Error().
+					Str("address", address).
+					Err(err).
+					Msg("Could not get validator slashes")
+				return
+			}
+
+			slashes = append(slashes, distributionRes.Slashes...)
+
+			if distributionRes.Pagination == nil || len(distributionRes.Pagination.NextKey) == 0 {
+				break
+			}
+
+			nextKey = distributionRes.Pagination.NextKey
+		}
+
+		sublogger.Debug().
+			Str("address", address).
+			Int("slashes", len(slashes)).
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying validator slashes")
+
+		validatorSlashesGauge.With(prometheus.Labels{
+			"address": address,
+			"moniker": validator.Validator.Description.Moniker,
+		}).Set(float64(len(slashes)))
+
+		// each slash takes a fraction of what's left after the previous ones
+		remaining := 1.0
+
+		for _, slash := range slashes {
+			// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
+			if fraction, err := strconv.ParseFloat(slash.Fraction.String(), 64); err != nil {
+				sublogger.Error().
+					Str("address", address).
+					Err(err).
+					Msg("Could not parse slash fraction")
+			} else {
+				remaining *= 1 - fraction
+			}
+		}
+
+		validatorSlashesFractionGauge.With(prometheus.Labels{
+			"address": address,
+			"moniker": validator.Validator.Description.Moniker,
+		}).Set(1 - remaining)
+
+		if len(slashes) == 0 {
+			return
+		}
+
+		lastSlashHeight, err := getLastSlashHeight(grpcConn, myAddress.String(), len(slashes))
+		if err != nil {
+			sublogger.Error().
+				Str("address", address).
+				Err(err).
+				Msg("Could not get last slash height")
+			return
+		}
+
+		validatorLastSlashHeightGauge.With(prometheus.Labels{
+			"address": address,
+			"moniker": validator.Validator.Description.Moniker,
+		}).Set(float64(lastSlashHeight))
+	}()
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -417,6 +1121,8 @@ func ValidatorHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cli
 			Float64("request-time", time.Since(queryStart).Seconds()).
 			Msg("Finished querying validator unbonding delegations")
 
+		unbondings := make([]delegatorSeries, 0, len(stakingRes.UnbondingResponses))
+
 		for _, unbonding := range stakingRes.UnbondingResponses {
 			var sum float64 = 0
 			for _, entry := range unbonding.Entries {
@@ -431,12 +1137,19 @@ func ValidatorHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cli
 				}
 			}
 
-			validatorUnbondingsGauge.With(prometheus.Labels{
-				"address":     unbonding.ValidatorAddress,
-				"moniker":     validator.Validator.Description.Moniker,
-				"denom":       Denom, // unbonding does not have denom in response for some reason
-				"unbonded_by": unbonding.DelegatorAddress,
-			}).Set(sum / DenomCoefficient)
+			unbondings = append(unbondings, delegatorSeries{
+				Labels: prometheus.Labels{
+					"address":     unbonding.ValidatorAddress,
+					"moniker":     validator.Validator.Description.Moniker,
+					"denom":       Denom, // unbonding does not have denom in response for some reason
+					"unbonded_by": unbonding.DelegatorAddress,
+				},
+				Value: sum / DenomCoefficient,
+			})
+		}
+
+		for _, unbonding := range limitDelegatorSeries(unbondings, "unbonded_by") {
+			validatorUnbondingsGauge.With(unbonding.Labels).Set(unbonding.Value)
 		}
 	}()
 
@@ -467,6 +1180,8 @@ func ValidatorHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cli
 			Float64("request-time", time.Since(queryStart).Seconds()).
 			Msg("Finished querying validator redelegations")
 
+		redelegations := make([]delegatorSeries, 0, len(stakingRes.RedelegationResponses))
+
 		for _, redelegation := range stakingRes.RedelegationResponses {
 			var sum float64 = 0
 			for _, entry := range redelegation.Entries {
@@ -481,13 +1196,20 @@ func ValidatorHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cli
 				}
 			}
 
-			validatorRedelegationsGauge.With(prometheus.Labels{
-				"address":        redelegation.Redelegation.ValidatorSrcAddress,
-				"moniker":        validator.Validator.Description.Moniker,
-				"denom":          Denom, // redelegation does not have denom in response for some reason
-				"redelegated_by": redelegation.Redelegation.DelegatorAddress,
-				"redelegated_to": redelegation.Redelegation.ValidatorDstAddress,
-			}).Set(sum / DenomCoefficient)
+			redelegations = append(redelegations, delegatorSeries{
+				Labels: prometheus.Labels{
+					"address":        redelegation.Redelegation.ValidatorSrcAddress,
+					"moniker":        validator.Validator.Description.Moniker,
+					"denom":          Denom, // redelegation does not have denom in response for some reason
+					"redelegated_by": redelegation.Redelegation.DelegatorAddress,
+					"redelegated_to": redelegation.Redelegation.ValidatorDstAddress,
+				},
+				Value: sum / DenomCoefficient,
+			})
+		}
+
+		for _, redelegation := range limitDelegatorSeries(redelegations, "redelegated_by", "redelegated_to") {
+			validatorRedelegationsGauge.With(redelegation.Labels).Set(redelegation.Value)
 		}
 	}()
 
@@ -500,17 +1222,6 @@ func ValidatorHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cli
 			Msg("Started querying validator signing info")
 		queryStart := time.Now()
 
-		encCfg := simapp.MakeTestEncodingConfig()
-		interfaceRegistry := encCfg.InterfaceRegistry
-
-		err := validator.Validator.UnpackInterfaces(interfaceRegistry) // Unpack interfaces, to populate the Anys' cached values
-		if err != nil {
-			sublogger.Error().
-				Str("address", address).
-				Err(err).
-				Msg("Could not get unpack validator inferfaces")
-		}
-
 		pubKey, err := validator.Validator.GetConsAddr()
 		if err != nil {
 			sublogger.Error().
@@ -546,6 +1257,76 @@ func ValidatorHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cli
 			"moniker": validator.Validator.Description.Moniker,
 			"address": address,
 		}).Set(float64(slashingRes.ValSigningInfo.MissedBlocksCounter))
+
+		signingInfo = &slashingRes.ValSigningInfo
+
+		setValidatorSigningInfo(validatorSigningInfoGauges{
+			StartHeight:     validatorStartHeightGauge,
+			IndexOffset:     validatorIndexOffsetGauge,
+			JailedUntil:     validatorJailedUntilGauge,
+			JailedRemaining: validatorJailedRemainingGauge,
+			Tombstoned:      validatorTombstonedGauge,
+			CanUnjail:       validatorCanUnjailGauge,
+		}, prometheus.Labels{
+			"moniker": validator.Validator.Description.Moniker,
+			"address": address,
+		}, validator.Validator.Jailed, slashingRes.ValSigningInfo)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		sublogger.Debug().
+			Str("address", address).
+			Msg("Started querying slashing params")
+		queryStart := time.Now()
+
+		slashingClient := slashingtypes.NewQueryClient(grpcConn)
+		paramsRes, err := slashingClient.Params(
+			context.Background(),
+			&slashingtypes.QueryParamsRequest{},
+		)
+		if err != nil {
+			sublogger.Error().
+				Str("address", address).
+				Err(err).
+				Msg("Could not get slashing params")
+			return
+		}
+
+		sublogger.Debug().
+			Str("address", address).
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying slashing params")
+
+		slashingParams = &paramsRes.Params
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		sublogger.Debug().
+			Str("address", address).
+			Msg("Started querying average block time")
+		queryStart := time.Now()
+
+		blockTime, err := getAverageBlockTime(grpcConn)
+		if err != nil {
+			sublogger.Error().
+				Str("address", address).
+				Err(err).
+				Msg("Could not get average block time")
+			return
+		}
+
+		sublogger.Debug().
+			Str("address", address).
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying average block time")
+
+		averageBlockTime = blockTime
 	}()
 
 	wg.Add(1)
@@ -656,6 +1437,17 @@ func ValidatorHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cli
 
 	wg.Wait()
 
+	if signingInfo != nil && slashingParams != nil {
+		setValidatorJailingRisk(validatorJailingRiskGauges{
+			MissedBlocksRatio: validatorMissedBlocksRatioGauge,
+			MissedBlocksLeft:  validatorMissedBlocksLeftGauge,
+			TimeToJail:        validatorTimeToJailGauge,
+		}, prometheus.Labels{
+			"moniker": validator.Validator.Description.Moniker,
+			"address": address,
+		}, *signingInfo, *slashingParams, averageBlockTime)
+	}
+
 	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
 	h.ServeHTTP(w, r)
 	sublogger.Info().
@@ -664,3 +1456,307 @@ func ValidatorHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cli
 		Float64("request-time", time.Since(requestStart).Seconds()).
 		Msg("Request processed")
 }
+
+const (
+	DelegationsModeFull      = "full"
+	DelegationsModeTop       = "top"
+	DelegationsModeAggregate = "aggregate"
+)
+
+type delegatorSeries struct {
+	Labels prometheus.Labels
+	Value  float64
+}
+
+// limitDelegatorSeries applies --delegations-mode to per-delegator series, as validators
+// with lots of delegators produce too many of them. In "top" mode only the largest ones
+// are kept and the rest are summed up into the "other" series, in "aggregate" mode
+// all of them are summed up into the "all" series.
+func limitDelegatorSeries(series []delegatorSeries, delegatorLabels ...string) []delegatorSeries {
+	var keep int
+	var bucket string
+
+	switch DelegationsMode {
+	case DelegationsModeTop:
+		keep = int(DelegationsTopN)
+		bucket = "other"
+	case DelegationsModeAggregate:
+		keep = 0
+		bucket = "all"
+	default:
+		return series
+	}
+
+	if len(series) <= keep {
+		return series
+	}
+
+	sorted := make([]delegatorSeries, len(series))
+	copy(sorted, series)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Value > sorted[j].Value
+	})
+
+	bucketSeries := delegatorSeries{Labels: prometheus.Labels{}}
+	for name, value := range sorted[0].Labels {
+		bucketSeries.Labels[name] = value
+	}
+
+	for _, name := range delegatorLabels {
+		bucketSeries.Labels[name] = bucket
+	}
+
+	for _, entry := range sorted[keep:] {
+		bucketSeries.Value += entry.Value
+	}
+
+	return append(sorted[:keep], bucketSeries)
+}
+
+// getLastSlashHeight takes the height of the most recent slash from its store key, as the height
+// isn't returned in the response. Slash events are stored by "height|period" keys, and the next key
+// of a page is the key of the slash right after it.
+func getLastSlashHeight(grpcConn *grpc.ClientConn, validatorAddress string, slashesCount int) (uint64, error) {
+	distributionClient := distributiontypes.NewQueryClient(grpcConn)
+
+	if slashesCount > 1 {
+		// the page with the slash before the last one points to the last one
+		response, err := distributionClient.ValidatorSlashes(
+			context.Background(),
+			&distributiontypes.QueryValidatorSlashesRequest{
+				ValidatorAddress: validatorAddress,
+				StartingHeight:   0,
+				EndingHeight:     math.MaxUint64,
+				Pagination: &querytypes.PageRequest{
+					Offset: uint64(slashesCount - 2),
+					Limit:  1,
+				},
+			},
+		)
+		if err != nil {
+			return 0, err
+		}
+
+		if response.Pagination == nil {
+			return 0, fmt.Errorf("no pagination in validator slashes response")
+		}
+
+		return getSlashHeightFromKey(response.Pagination.NextKey)
+	}
+
+	// the first slash is never pointed to by the next key, so bisecting the heights instead,
+	// using the height as the page key, which starts the page from the first slash at or after it
+	serviceClient := tmservice.NewServiceClient(grpcConn)
+	latestBlock, err := serviceClient.GetLatestBlock(
+		context.Background(),
+		&tmservice.GetLatestBlockRequest{},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	hasSlashesSince := func(height uint64) (bool, error) {
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, height)
+
+		response, err := distributionClient.ValidatorSlashes(
+			context.Background(),
+			&distributiontypes.QueryValidatorSlashesRequest{
+				ValidatorAddress: validatorAddress,
+				StartingHeight:   0,
+				EndingHeight:     math.MaxUint64,
+				Pagination: &querytypes.PageRequest{
+					Key:   key,
+					Limit: 1,
+				},
+			},
+		)
+		if err != nil {
+			return false, err
+		}
+
+		return len(response.Slashes) > 0, nil
+	}
+
+	// there are slashes since the lowest height and none since the highest one
+	lowest, highest := uint64(0), uint64(latestBlock.Block.Header.Height)+1
+	for highest-lowest > 1 {
+		middle := lowest + (highest-lowest)/2
+
+		found, err := hasSlashesSince(middle)
+		if err != nil {
+			return 0, err
+		}
+
+		if found {
+			lowest = middle
+		} else {
+			highest = middle
+		}
+	}
+
+	return lowest, nil
+}
+
+func getSlashHeightFromKey(key []byte) (uint64, error) {
+	if len(key) != 16 {
+		return 0, fmt.Errorf("unexpected slash event key length %d", len(key))
+	}
+
+	return binary.BigEndian.Uint64(key[:8]), nil
+}
+
+// getMissedBlocksLeft calculates the amount of blocks a validator can miss
+// within the current signed blocks window without being jailed for downtime.
+func getMissedBlocksLeft(params slashingtypes.Params, missedBlocks int64) int64 {
+	// that's how x/slashing calculates it, see HandleValidatorSignature
+	minSignedPerWindow := params.MinSignedPerWindow.MulInt64(params.SignedBlocksWindow).RoundInt64()
+	maxMissed := params.SignedBlocksWindow - minSignedPerWindow
+
+	if missedBlocks >= maxMissed {
+		return 0
+	}
+
+	return maxMissed - missedBlocks
+}
+
+// validatorSigningInfoGauges are the gauges taken from the validator signing info,
+// exported by both /metrics/validator and /metrics/validators with their own prefixes.
+type validatorSigningInfoGauges struct {
+	StartHeight     *prometheus.GaugeVec
+	IndexOffset     *prometheus.GaugeVec
+	JailedUntil     *prometheus.GaugeVec
+	JailedRemaining *prometheus.GaugeVec
+	Tombstoned      *prometheus.GaugeVec
+	CanUnjail       *prometheus.GaugeVec
+}
+
+func setValidatorSigningInfo(
+	gauges validatorSigningInfoGauges,
+	labels prometheus.Labels,
+	jailed bool,
+	signingInfo slashingtypes.ValidatorSigningInfo,
+) {
+	gauges.StartHeight.With(labels).Set(float64(signingInfo.StartHeight))
+	gauges.IndexOffset.With(labels).Set(float64(signingInfo.IndexOffset))
+	gauges.JailedUntil.With(labels).Set(float64(signingInfo.JailedUntil.Unix()))
+
+	jailedRemaining := time.Until(signingInfo.JailedUntil).Seconds()
+	if jailedRemaining < 0 {
+		jailedRemaining = 0
+	}
+
+	gauges.JailedRemaining.With(labels).Set(jailedRemaining)
+
+	var tombstoned float64
+	if signingInfo.Tombstoned {
+		tombstoned = 1
+	}
+
+	gauges.Tombstoned.With(labels).Set(tombstoned)
+
+	// a tombstoned validator can never be unjailed
+	var canUnjail float64
+	if jailed && !signingInfo.Tombstoned && jailedRemaining == 0 {
+		canUnjail = 1
+	}
+
+	gauges.CanUnjail.With(labels).Set(canUnjail)
+}
+
+// validatorJailingRiskGauges are the gauges showing how close the validator is to being
+// jailed for downtime, exported by both /metrics/validator and /metrics/validators.
+type validatorJailingRiskGauges struct {
+	MissedBlocksRatio *prometheus.GaugeVec
+	MissedBlocksLeft  *prometheus.GaugeVec
+	TimeToJail        *prometheus.GaugeVec
+}
+
+// setValidatorJailingRisk skips the time to jail if the average block time is unknown.
+func setValidatorJailingRisk(
+	gauges validatorJailingRiskGauges,
+	labels prometheus.Labels,
+	signingInfo slashingtypes.ValidatorSigningInfo,
+	params slashingtypes.Params,
+	averageBlockTime time.Duration,
+) {
+	if params.SignedBlocksWindow <= 0 {
+		return
+	}
+
+	gauges.MissedBlocksRatio.With(labels).Set(float64(signingInfo.MissedBlocksCounter) / float64(params.SignedBlocksWindow))
+
+	missedBlocksLeft := getMissedBlocksLeft(params, signingInfo.MissedBlocksCounter)
+	gauges.MissedBlocksLeft.With(labels).Set(float64(missedBlocksLeft))
+
+	if averageBlockTime != 0 {
+		// the validator is jailed on the first miss after it has no blocks to miss left
+		gauges.TimeToJail.With(labels).Set(float64(missedBlocksLeft+1) * averageBlockTime.Seconds())
+	}
+}
+
+var validatorInfoLabels = []string{
+	"address",
+	"moniker",
+	"identity",
+	"website",
+	"security_contact",
+	"details_hash",
+	"consensus_pubkey",
+	"consensus_address",
+	"consensus_address_hex",
+	"account_address",
+}
+
+// getValidatorInfoLabels expects the validator interfaces to be already unpacked,
+// otherwise the consensus keys would be left empty.
+func getValidatorInfoLabels(validator stakingtypes.Validator, sublogger zerolog.Logger) prometheus.Labels {
+	// details can be long and multiline, so only exposing its hash to detect changes
+	detailsHash := sha256.Sum256([]byte(validator.Description.Details))
+
+	labels := prometheus.Labels{
+		"address":               validator.OperatorAddress,
+		"moniker":               validator.Description.Moniker,
+		"identity":              validator.Description.Identity,
+		"website":               validator.Description.Website,
+		"security_contact":      validator.Description.SecurityContact,
+		"details_hash":          hex.EncodeToString(detailsHash[:]),
+		"consensus_pubkey":      "",
+		"consensus_address":     "",
+		"consensus_address_hex": "",
+		"account_address":       "",
+	}
+
+	if valAddress, err := sdk.ValAddressFromBech32(validator.OperatorAddress); err != nil {
+		sublogger.Error().
+			Str("address", validator.OperatorAddress).
+			Err(err).
+			Msg("Could not parse validator address")
+	} else {
+		labels["account_address"] = sdk.AccAddress(valAddress).String()
+	}
+
+	consPubKey, err := validator.ConsPubKey()
+	if err != nil {
+		sublogger.Error().
+			Str("address", validator.OperatorAddress).
+			Err(err).
+			Msg("Could not get validator consensus pubkey")
+		return labels
+	}
+
+	if bech32PubKey, err := sdk.Bech32ifyPubKey(sdk.Bech32PubKeyTypeConsPub, consPubKey); err != nil {
+		sublogger.Error().
+			Str("address", validator.OperatorAddress).
+			Err(err).
+			Msg("Could not convert validator consensus pubkey to bech32")
+	} else {
+		labels["consensus_pubkey"] = bech32PubKey
+	}
+
+	consAddress := sdk.ConsAddress(consPubKey.Address())
+	labels["consensus_address"] = consAddress.String()
+	labels["consensus_address_hex"] = strings.ToUpper(hex.EncodeToString(consAddress))
+
+	return labels
+}
@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"encoding/hex"
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -37,6 +39,15 @@ func ValidatorsHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cl
 		[]string{"address", "moniker"},
 	)
 
+	validatorsAPRGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_apr",
+			Help:        "Staking APR for delegators of the Cosmos-based blockchain validator, after its commission, 0 if it is jailed or not bonded",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
 	validatorsStatusGauge := prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name:        "cosmos_validators_status",
@@ -91,6 +102,87 @@ func ValidatorsHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cl
 		[]string{"address", "moniker"},
 	)
 
+	validatorsMissedBlocksRatioGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_missed_blocks_ratio",
+			Help:        "Missed blocks of the Cosmos-based blockchain validator as a fraction of the signed blocks window",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorsMissedBlocksLeftGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_missed_blocks_left",
+			Help:        "Amount of blocks the Cosmos-based blockchain validator can miss more before being jailed",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorsTimeToJailGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_time_to_jail_seconds",
+			Help:        "Estimated time until the Cosmos-based blockchain validator is jailed if it stops signing now, in seconds",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorsStartHeightGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_start_height",
+			Help:        "Height at which the Cosmos-based blockchain validator was first a candidate or was unjailed",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorsIndexOffsetGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_index_offset",
+			Help:        "Signing info index offset of the Cosmos-based blockchain validator",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorsJailedUntilGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_jailed_until",
+			Help:        "Timestamp until which the Cosmos-based blockchain validator is jailed, in unix seconds",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorsJailedRemainingGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_jailed_remaining_seconds",
+			Help:        "Seconds left until the Cosmos-based blockchain validator can be unjailed, 0 if it can be already",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorsTombstonedGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_tombstoned",
+			Help:        "1 if the Cosmos-based blockchain validator is tombstoned, 0 if no",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorsCanUnjailGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_can_unjail",
+			Help:        "1 if the Cosmos-based blockchain validator is jailed and can be unjailed now, 0 if no",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
 	validatorsRankGauge := prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name:        "cosmos_validators_rank",
@@ -100,6 +192,42 @@ func ValidatorsHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cl
 		[]string{"address", "moniker"},
 	)
 
+	validatorsVotingPowerShareGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_voting_power_share",
+			Help:        "Voting power of the Cosmos-based blockchain validator as a fraction of total bonded tokens",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorsCumulativeVotingPowerShareGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_cumulative_voting_power_share",
+			Help:        "Voting power of the Cosmos-based blockchain validator and all validators ranked above it as a fraction of total bonded tokens",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorsTopThirdGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_top_33_percent",
+			Help:        "1 if the Cosmos-based blockchain validator is within the validators holding the first 33% of voting power, 0 if no",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorsTopTwoThirdsGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_top_67_percent",
+			Help:        "1 if the Cosmos-based blockchain validator is within the validators holding the first 67% of voting power, 0 if no",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
 	validatorsIsActiveGauge := prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name:        "cosmos_validators_active",
@@ -109,20 +237,47 @@ func ValidatorsHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cl
 		[]string{"address", "moniker"},
 	)
 
+	validatorsInfoGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_info",
+			Help:        "Description and keys of the Cosmos-based blockchain validator, always 1",
+			ConstLabels: ConstLabels,
+		},
+		validatorInfoLabels,
+	)
+
 	registry := prometheus.NewRegistry()
 	registry.MustRegister(validatorsCommissionGauge)
+	registry.MustRegister(validatorsAPRGauge)
 	registry.MustRegister(validatorsStatusGauge)
 	registry.MustRegister(validatorsJailedGauge)
 	registry.MustRegister(validatorsTokensGauge)
 	registry.MustRegister(validatorsDelegatorSharesGauge)
 	registry.MustRegister(validatorsMinSelfDelegationGauge)
 	registry.MustRegister(validatorsMissedBlocksGauge)
+	registry.MustRegister(validatorsMissedBlocksRatioGauge)
+	registry.MustRegister(validatorsMissedBlocksLeftGauge)
+	registry.MustRegister(validatorsTimeToJailGauge)
+	registry.MustRegister(validatorsStartHeightGauge)
+	registry.MustRegister(validatorsIndexOffsetGauge)
+	registry.MustRegister(validatorsJailedUntilGauge)
+	registry.MustRegister(validatorsJailedRemainingGauge)
+	registry.MustRegister(validatorsTombstonedGauge)
+	registry.MustRegister(validatorsCanUnjailGauge)
 	registry.MustRegister(validatorsRankGauge)
 	registry.MustRegister(validatorsIsActiveGauge)
+	registry.MustRegister(validatorsVotingPowerShareGauge)
+	registry.MustRegister(validatorsCumulativeVotingPowerShareGauge)
+	registry.MustRegister(validatorsTopThirdGauge)
+	registry.MustRegister(validatorsTopTwoThirdsGauge)
+	registry.MustRegister(validatorsInfoGauge)
 
 	var validators []stakingtypes.Validator
 	var signingInfos []slashingtypes.ValidatorSigningInfo
 	var validatorSetLength uint32
+	var slashingParams *slashingtypes.Params
+	var averageBlockTime time.Duration
+	var stakingAPR *float64
 
 	var wg sync.WaitGroup
 
@@ -209,6 +364,70 @@ func ValidatorsHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cl
 		validatorSetLength = paramsResponse.Params.MaxValidators
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sublogger.Debug().Msg("Started querying slashing params")
+		queryStart := time.Now()
+
+		slashingClient := slashingtypes.NewQueryClient(grpcConn)
+		paramsResponse, err := slashingClient.Params(
+			context.Background(),
+			&slashingtypes.QueryParamsRequest{},
+		)
+		if err != nil {
+			sublogger.Error().
+				Err(err).
+				Msg("Could not get slashing params")
+			return
+		}
+
+		sublogger.Debug().
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying slashing params")
+		slashingParams = &paramsResponse.Params
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sublogger.Debug().Msg("Started querying average block time")
+		queryStart := time.Now()
+
+		blockTime, err := getAverageBlockTime(grpcConn)
+		if err != nil {
+			sublogger.Error().
+				Err(err).
+				Msg("Could not get average block time")
+			return
+		}
+
+		sublogger.Debug().
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying average block time")
+		averageBlockTime = blockTime
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sublogger.Debug().Msg("Started calculating staking APR")
+		queryStart := time.Now()
+
+		apr, err := APRSource.GetStakingAPR(grpcConn)
+		if err != nil {
+			sublogger.Error().
+				Err(err).
+				Msg("Could not calculate staking APR")
+			return
+		}
+
+		sublogger.Debug().
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished calculating staking APR")
+		stakingAPR = &apr
+	}()
+
 	wg.Wait()
 
 	sublogger.Debug().
@@ -216,6 +435,27 @@ func ValidatorsHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cl
 		Int("validatorsLength", len(validators)).
 		Msg("Validators info")
 
+	// only bonded validators have voting power
+	var totalBondedTokens float64
+
+	for _, validator := range validators {
+		if validator.Status != stakingtypes.Bonded {
+			continue
+		}
+
+		if value, err := strconv.ParseFloat(validator.Tokens.String(), 64); err != nil {
+			sublogger.Error().
+				Str("address", validator.OperatorAddress).
+				Err(err).
+				Msg("Could not parse validator tokens")
+		} else {
+			totalBondedTokens += value
+		}
+	}
+
+	// voting power of all bonded validators ranked higher than the current one
+	var cumulativeVotingPowerShare float64
+
 	for index, validator := range validators {
 		// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
 		rate, err := strconv.ParseFloat(validator.Commission.CommissionRates.Rate.String(), 64)
@@ -229,6 +469,19 @@ func ValidatorsHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cl
 				"address": validator.OperatorAddress,
 				"moniker": validator.Description.Moniker,
 			}).Set(rate)
+
+			if stakingAPR != nil {
+				// jailed and not bonded validators don't earn any rewards
+				validatorAPR := *stakingAPR * (1 - rate)
+				if validator.Jailed || !validator.IsBonded() {
+					validatorAPR = 0
+				}
+
+				validatorsAPRGauge.With(prometheus.Labels{
+					"address": validator.OperatorAddress,
+					"moniker": validator.Description.Moniker,
+				}).Set(validatorAPR)
+			}
 		}
 
 		validatorsStatusGauge.With(prometheus.Labels{
@@ -291,6 +544,54 @@ func ValidatorsHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cl
 			}).Set(value / DenomCoefficient)
 		}
 
+		if validator.Status == stakingtypes.Bonded && totalBondedTokens > 0 {
+			if value, err := strconv.ParseFloat(validator.Tokens.String(), 64); err != nil {
+				sublogger.Error().
+					Str("address", validator.OperatorAddress).
+					Err(err).
+					Msg("Could not parse validator tokens")
+			} else {
+				votingPowerShare := value / totalBondedTokens
+
+				// golang doesn't have a ternary operator, so we have to stick with this ugly solution
+				var topThird, topTwoThirds float64
+
+				if cumulativeVotingPowerShare < 1.0/3 {
+					topThird = 1
+				} else {
+					topThird = 0
+				}
+
+				if cumulativeVotingPowerShare < 2.0/3 {
+					topTwoThirds = 1
+				} else {
+					topTwoThirds = 0
+				}
+
+				cumulativeVotingPowerShare += votingPowerShare
+
+				validatorsVotingPowerShareGauge.With(prometheus.Labels{
+					"address": validator.OperatorAddress,
+					"moniker": validator.Description.Moniker,
+				}).Set(votingPowerShare)
+
+				validatorsCumulativeVotingPowerShareGauge.With(prometheus.Labels{
+					"address": validator.OperatorAddress,
+					"moniker": validator.Description.Moniker,
+				}).Set(cumulativeVotingPowerShare)
+
+				validatorsTopThirdGauge.With(prometheus.Labels{
+					"address": validator.OperatorAddress,
+					"moniker": validator.Description.Moniker,
+				}).Set(topThird)
+
+				validatorsTopTwoThirdsGauge.With(prometheus.Labels{
+					"address": validator.OperatorAddress,
+					"moniker": validator.Description.Moniker,
+				}).Set(topTwoThirds)
+			}
+		}
+
 		err = validator.UnpackInterfaces(interfaceRegistry) // Unpack interfaces, to populate the Anys' cached values
 		if err != nil {
 			sublogger.Error().
@@ -299,6 +600,8 @@ func ValidatorsHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cl
 				Msg("Could not get unpack validator inferfaces")
 		}
 
+		validatorsInfoGauge.With(getValidatorInfoLabels(validator, sublogger)).Set(1)
+
 		pubKey, err := validator.GetConsAddr()
 		if err != nil {
 			sublogger.Error().
@@ -325,11 +628,34 @@ func ValidatorsHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cl
 			continue
 		}
 
+		setValidatorSigningInfo(validatorSigningInfoGauges{
+			StartHeight:     validatorsStartHeightGauge,
+			IndexOffset:     validatorsIndexOffsetGauge,
+			JailedUntil:     validatorsJailedUntilGauge,
+			JailedRemaining: validatorsJailedRemainingGauge,
+			Tombstoned:      validatorsTombstonedGauge,
+			CanUnjail:       validatorsCanUnjailGauge,
+		}, prometheus.Labels{
+			"address": validator.OperatorAddress,
+			"moniker": validator.Description.Moniker,
+		}, validator.Jailed, signingInfo)
+
 		if validator.Status == stakingtypes.Bonded {
 			validatorsMissedBlocksGauge.With(prometheus.Labels{
 				"address": validator.OperatorAddress,
 				"moniker": validator.Description.Moniker,
 			}).Set(float64(signingInfo.MissedBlocksCounter))
+
+			if slashingParams != nil {
+				setValidatorJailingRisk(validatorJailingRiskGauges{
+					MissedBlocksRatio: validatorsMissedBlocksRatioGauge,
+					MissedBlocksLeft:  validatorsMissedBlocksLeftGauge,
+					TimeToJail:        validatorsTimeToJailGauge,
+				}, prometheus.Labels{
+					"address": validator.OperatorAddress,
+					"moniker": validator.Description.Moniker,
+				}, signingInfo, *slashingParams, averageBlockTime)
+			}
 		} else {
 			sublogger.Trace().
 				Str("address", validator.OperatorAddress).
@@ -366,3 +692,40 @@ func ValidatorsHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Cl
 		Float64("request-time", time.Since(requestStart).Seconds()).
 		Msg("Request processed")
 }
+
+// getValidatorsByConsensusAddress returns all validators keyed by their hex consensus address,
+// as that's how Tendermint refers to them.
+func getValidatorsByConsensusAddress(grpcConn *grpc.ClientConn) (map[string]stakingtypes.Validator, error) {
+	encCfg := simapp.MakeTestEncodingConfig()
+	interfaceRegistry := encCfg.InterfaceRegistry
+
+	stakingClient := stakingtypes.NewQueryClient(grpcConn)
+	validatorsResponse, err := stakingClient.Validators(
+		context.Background(),
+		&stakingtypes.QueryValidatorsRequest{
+			Pagination: &querytypes.PageRequest{
+				Limit: Limit,
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	validators := make(map[string]stakingtypes.Validator, len(validatorsResponse.Validators))
+
+	for _, validator := range validatorsResponse.Validators {
+		if err := validator.UnpackInterfaces(interfaceRegistry); err != nil {
+			return nil, err
+		}
+
+		consAddress, err := validator.GetConsAddr()
+		if err != nil {
+			return nil, err
+		}
+
+		validators[strings.ToUpper(hex.EncodeToString(consAddress))] = validator
+	}
+
+	return validators, nil
+}
@@ -2,22 +2,33 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"strconv"
 	"sync"
 	"time"
 
+	"github.com/cosmos/cosmos-sdk/simapp"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	querytypes "github.com/cosmos/cosmos-sdk/types/query"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 	distributiontypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
 	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	tmrpc "github.com/tendermint/tendermint/rpc/client/http"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-func WalletHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.ClientConn) {
+func WalletHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.ClientConn, tendermintClient *tmrpc.HTTP) {
+	encCfg := simapp.MakeTestEncodingConfig()
+	interfaceRegistry := encCfg.InterfaceRegistry
+
 	requestStart := time.Now()
 
 	sublogger := log.With().
@@ -79,12 +90,82 @@ func WalletHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Client
 		[]string{"address", "denom", "validator_address"},
 	)
 
+	walletAccountNumberGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_wallet_account_number",
+			Help:        "Account number of the Cosmos-based blockchain wallet",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address"},
+	)
+
+	walletSequenceGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_wallet_sequence",
+			Help:        "Sequence of the Cosmos-based blockchain wallet, increases with each transaction sent",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address"},
+	)
+
+	walletLastTxHeightGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_wallet_last_tx_height",
+			Help:        "Height of the latest transaction sent by the Cosmos-based blockchain wallet",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address"},
+	)
+
+	walletLastTxTimeGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_wallet_last_tx_time",
+			Help:        "Time of the latest transaction sent by the Cosmos-based blockchain wallet, in unix seconds",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address"},
+	)
+
+	walletWindowTxsGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_wallet_window_txs",
+			Help:        "Amount of transactions sent by the Cosmos-based blockchain wallet in the last --wallet-txs-window blocks",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address"},
+	)
+
+	walletWithdrawAddressGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_wallet_withdraw_address",
+			Help:        "Withdraw address of the Cosmos-based blockchain wallet, always 1",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "withdraw_address"},
+	)
+
+	walletWithdrawAddressMismatchGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_wallet_withdraw_address_mismatch",
+			Help:        "1 if the wallet withdraw address differs from the expected one (or from the wallet itself if not set), 0 otherwise",
+			ConstLabels: ConstLabels,
+		},
+		[]string{"address", "expected_withdraw_address"},
+	)
+
 	registry := prometheus.NewRegistry()
 	registry.MustRegister(walletBalanceGauge)
 	registry.MustRegister(walletDelegationGauge)
 	registry.MustRegister(walletUnbondingsGauge)
 	registry.MustRegister(walletRedelegationGauge)
 	registry.MustRegister(walletRewardsGauge)
+	registry.MustRegister(walletAccountNumberGauge)
+	registry.MustRegister(walletSequenceGauge)
+	registry.MustRegister(walletLastTxHeightGauge)
+	registry.MustRegister(walletLastTxTimeGauge)
+	registry.MustRegister(walletWindowTxsGauge)
+	registry.MustRegister(walletWithdrawAddressGauge)
+	registry.MustRegister(walletWithdrawAddressMismatchGauge)
 
 	var wg sync.WaitGroup
 
@@ -315,6 +396,201 @@ func WalletHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Client
 		}
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		sublogger.Debug().
+			Str("address", address).
+			Msg("Started querying withdraw address")
+		queryStart := time.Now()
+
+		distributionClient := distributiontypes.NewQueryClient(grpcConn)
+		distributionRes, err := distributionClient.DelegatorWithdrawAddress(
+			context.Background(),
+			&distributiontypes.QueryDelegatorWithdrawAddressRequest{DelegatorAddress: myAddress.String()},
+		)
+		if err != nil {
+			sublogger.Error().
+				Str("address", address).
+				Err(err).
+				Msg("Could not get withdraw address")
+			return
+		}
+
+		sublogger.Debug().
+			Str("address", address).
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying withdraw address")
+
+		walletWithdrawAddressGauge.With(prometheus.Labels{
+			"address":          address,
+			"withdraw_address": distributionRes.WithdrawAddress,
+		}).Set(1)
+
+		expectedWithdrawAddress := getExpectedWithdrawAddress(r, myAddress.String())
+		walletWithdrawAddressMismatchGauge.With(prometheus.Labels{
+			"address":                   address,
+			"expected_withdraw_address": expectedWithdrawAddress,
+		}).Set(getWithdrawAddressMismatch(distributionRes.WithdrawAddress, expectedWithdrawAddress))
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		sublogger.Debug().
+			Str("address", address).
+			Msg("Started querying account")
+		queryStart := time.Now()
+
+		authClient := authtypes.NewQueryClient(grpcConn)
+		accountRes, err := authClient.Account(
+			context.Background(),
+			&authtypes.QueryAccountRequest{Address: myAddress.String()},
+		)
+		if err != nil {
+			// the account doesn't exist until it receives some tokens
+			if status.Code(err) == codes.NotFound {
+				sublogger.Debug().
+					Str("address", address).
+					Msg("Account not found")
+				return
+			}
+
+			sublogger.Error().
+				Str("address", address).
+				Err(err).
+				Msg("Could not get account")
+			return
+		}
+
+		sublogger.Debug().
+			Str("address", address).
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying account")
+
+		var account authtypes.AccountI
+		if err := interfaceRegistry.UnpackAny(accountRes.Account, &account); err != nil {
+			sublogger.Error().
+				Str("address", address).
+				Str("type", accountRes.Account.TypeUrl).
+				Err(err).
+				Msg("Could not unpack account")
+			return
+		}
+
+		walletAccountNumberGauge.With(prometheus.Labels{
+			"address": address,
+		}).Set(float64(account.GetAccountNumber()))
+
+		walletSequenceGauge.With(prometheus.Labels{
+			"address": address,
+		}).Set(float64(account.GetSequence()))
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		sublogger.Debug().
+			Str("address", address).
+			Msg("Started querying latest transaction")
+		queryStart := time.Now()
+
+		txClient := txtypes.NewServiceClient(grpcConn)
+		txsRes, err := txClient.GetTxsEvent(
+			context.Background(),
+			&txtypes.GetTxsEventRequest{
+				Events: []string{"message.sender='" + myAddress.String() + "'"},
+				Pagination: &querytypes.PageRequest{
+					Limit: 1,
+				},
+				OrderBy: txtypes.OrderBy_ORDER_BY_DESC,
+			},
+		)
+		if err != nil {
+			sublogger.Error().
+				Str("address", address).
+				Err(err).
+				Msg("Could not get latest transaction")
+			return
+		}
+
+		sublogger.Debug().
+			Str("address", address).
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying latest transaction")
+
+		if len(txsRes.TxResponses) == 0 {
+			return
+		}
+
+		latestTx := txsRes.TxResponses[0]
+		walletLastTxHeightGauge.With(prometheus.Labels{
+			"address": address,
+		}).Set(float64(latestTx.Height))
+
+		if txTime, err := time.Parse(time.RFC3339, latestTx.Timestamp); err != nil {
+			sublogger.Error().
+				Str("address", address).
+				Str("timestamp", latestTx.Timestamp).
+				Err(err).
+				Msg("Could not parse latest transaction time")
+		} else {
+			walletLastTxTimeGauge.With(prometheus.Labels{
+				"address": address,
+			}).Set(float64(txTime.Unix()))
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		sublogger.Debug().
+			Str("address", address).
+			Msg("Started querying transactions in window")
+		queryStart := time.Now()
+
+		// querying Tendermint directly, as newer SDK versions don't allow
+		// height ranges in the tx service events
+		nodeStatus, err := tendermintClient.Status(context.Background())
+		if err != nil {
+			sublogger.Error().
+				Str("address", address).
+				Err(err).
+				Msg("Could not get node status")
+			return
+		}
+
+		fromHeight := nodeStatus.SyncInfo.LatestBlockHeight - int64(WalletTxsWindow) + 1
+		if fromHeight < 1 {
+			fromHeight = 1
+		}
+
+		query := fmt.Sprintf("message.sender='%s' AND tx.height>=%d", myAddress.String(), fromHeight)
+		page, perPage := 1, 1
+
+		txsRes, err := tendermintClient.TxSearch(context.Background(), query, false, &page, &perPage, "")
+		if err != nil {
+			sublogger.Error().
+				Str("address", address).
+				Err(err).
+				Msg("Could not get transactions in window")
+			return
+		}
+
+		sublogger.Debug().
+			Str("address", address).
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying transactions in window")
+
+		walletWindowTxsGauge.With(prometheus.Labels{
+			"address": address,
+		}).Set(float64(txsRes.TotalCount))
+	}()
+
 	wg.Wait()
 
 	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
@@ -325,3 +601,21 @@ func WalletHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.Client
 		Float64("request-time", time.Since(requestStart).Seconds()).
 		Msg("Request processed")
 }
+
+// getExpectedWithdrawAddress takes the expected withdraw address from the request params,
+// so it can be set per target in the Prometheus config, defaulting to the wallet itself.
+func getExpectedWithdrawAddress(r *http.Request, walletAddress string) string {
+	if expected := r.URL.Query().Get("expected_withdraw_address"); expected != "" {
+		return expected
+	}
+
+	return walletAddress
+}
+
+func getWithdrawAddressMismatch(withdrawAddress string, expectedWithdrawAddress string) float64 {
+	if withdrawAddress != expectedWithdrawAddress {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+)
+
+// WasmMetric is a metric taken from the CosmWasm contract smart query response,
+// configured in the config file as:
+//
+// [[wasm-metrics]]
+// name = "staked_tokens"
+// help = "Tokens staked via the liquid staking contract"
+// contract = "juno1..."
+// query = '{"state":{}}'
+// items = "validators"
+// value = "amount"
+// [wasm-metrics.labels]
+// validator = "address"
+type WasmMetric struct {
+	JSONMetric `mapstructure:",squash"`
+	Contract   string `mapstructure:"contract"`
+	Query      string `mapstructure:"query"`
+}
+
+func (m WasmMetric) Validate() error {
+	if err := m.JSONMetric.Validate(); err != nil {
+		return err
+	}
+
+	if m.Contract == "" {
+		return fmt.Errorf("contract is not set for metric %s", m.Name)
+	}
+
+	if !json.Valid([]byte(m.Query)) {
+		return fmt.Errorf("query is not a valid JSON for metric %s", m.Name)
+	}
+
+	return nil
+}
+
+type wasmSmartContractStateRequest struct {
+	Address   string `protobuf:"bytes,1,opt,name=address,proto3"`
+	QueryData []byte `protobuf:"bytes,2,opt,name=query_data,json=queryData,proto3"`
+}
+
+func (m *wasmSmartContractStateRequest) Reset()         { *m = wasmSmartContractStateRequest{} }
+func (m *wasmSmartContractStateRequest) String() string { return proto.CompactTextString(m) }
+func (*wasmSmartContractStateRequest) ProtoMessage()    {}
+
+type wasmSmartContractStateResponse struct {
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3"`
+}
+
+func (m *wasmSmartContractStateResponse) Reset()         { *m = wasmSmartContractStateResponse{} }
+func (m *wasmSmartContractStateResponse) String() string { return proto.CompactTextString(m) }
+func (*wasmSmartContractStateResponse) ProtoMessage()    {}
+
+func querySmartContractState(grpcConn *grpc.ClientConn, contract string, query string) (interface{}, error) {
+	response := &wasmSmartContractStateResponse{}
+	if err := grpcConn.Invoke(
+		context.Background(),
+		"/cosmwasm.wasm.v1.Query/SmartContractState",
+		&wasmSmartContractStateRequest{
+			Address:   contract,
+			QueryData: []byte(query),
+		},
+		response,
+	); err != nil {
+		return nil, err
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(response.Data, &data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func WasmHandler(w http.ResponseWriter, r *http.Request, grpcConn *grpc.ClientConn) {
+	requestStart := time.Now()
+
+	sublogger := log.With().
+		Str("request-id", uuid.New().String()).
+		Logger()
+
+	registry := prometheus.NewRegistry()
+
+	var wg sync.WaitGroup
+
+	for _, metric := range WasmMetrics {
+		gauge := prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_wasm_" + metric.Name,
+				Help:        metric.Help,
+				ConstLabels: ConstLabels,
+			},
+			metric.LabelNames("contract"),
+		)
+
+		if err := registry.Register(gauge); err != nil {
+			sublogger.Error().
+				Str("metric", metric.Name).
+				Err(err).
+				Msg("Could not register wasm metric")
+			continue
+		}
+
+		wg.Add(1)
+		go func(metric WasmMetric, gauge *prometheus.GaugeVec) {
+			defer wg.Done()
+
+			metricLogger := sublogger.With().
+				Str("metric", metric.Name).
+				Str("contract", metric.Contract).
+				Logger()
+
+			metricLogger.Debug().Msg("Started querying contract state")
+			queryStart := time.Now()
+
+			data, err := querySmartContractState(grpcConn, metric.Contract, metric.Query)
+			if err != nil {
+				metricLogger.Error().Err(err).Msg("Could not query contract state")
+				return
+			}
+
+			metricLogger.Debug().
+				Float64("request-time", time.Since(queryStart).Seconds()).
+				Msg("Finished querying contract state")
+
+			metric.Set(gauge, data, prometheus.Labels{"contract": metric.Contract}, metricLogger)
+		}(metric, gauge)
+	}
+
+	wg.Wait()
+
+	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	h.ServeHTTP(w, r)
+	sublogger.Info().
+		Str("method", "GET").
+		Str("endpoint", "/metrics/wasm").
+		Float64("request-time", time.Since(requestStart).Seconds()).
+		Msg("Request processed")
+}